@@ -0,0 +1,60 @@
+package api
+
+import "encoding/json"
+
+// The generator emits the inline oneOf in the /search response as
+// GetSearch_200_Results_Item but, unlike named unions, without accessors
+// or JSON methods, so the results could never be decoded. These mirror
+// what oapi-codegen generates for named unions.
+
+// AsTrack returns the union data inside the GetSearch_200_Results_Item as a Track
+func (t GetSearch_200_Results_Item) AsTrack() (Track, error) {
+	var body Track
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromTrack overwrites any union data inside the GetSearch_200_Results_Item as the provided Track
+func (t *GetSearch_200_Results_Item) FromTrack(v Track) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// AsAlbum returns the union data inside the GetSearch_200_Results_Item as a Album
+func (t GetSearch_200_Results_Item) AsAlbum() (Album, error) {
+	var body Album
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromAlbum overwrites any union data inside the GetSearch_200_Results_Item as the provided Album
+func (t *GetSearch_200_Results_Item) FromAlbum(v Album) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// AsArtist returns the union data inside the GetSearch_200_Results_Item as a Artist
+func (t GetSearch_200_Results_Item) AsArtist() (Artist, error) {
+	var body Artist
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromArtist overwrites any union data inside the GetSearch_200_Results_Item as the provided Artist
+func (t *GetSearch_200_Results_Item) FromArtist(v Artist) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+func (t GetSearch_200_Results_Item) MarshalJSON() ([]byte, error) {
+	b, err := t.union.MarshalJSON()
+	return b, err
+}
+
+func (t *GetSearch_200_Results_Item) UnmarshalJSON(b []byte) error {
+	err := t.union.UnmarshalJSON(b)
+	return err
+}
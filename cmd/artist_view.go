@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/artist"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+)
+
+// artistPage is one artist on the artist stack. Following a similar
+// artist pushes a new page; Esc pops back along the path.
+type artistPage struct {
+	artist api.Artist
+	albums []api.Album
+	cursor int
+
+	similar        []api.Artist
+	similarOpen    bool
+	similarLoading bool
+	similarCursor  int
+	err            string
+}
+
+type similarMsg struct {
+	page    *artistPage
+	artists []api.Artist
+	err     error
+}
+
+func loadSimilar(page *artistPage) tea.Cmd {
+	return func() tea.Msg {
+		artists, err := artist.Similar(page.artist)
+		return similarMsg{page: page, artists: artists, err: err}
+	}
+}
+
+var bioStyle = lipgloss.NewStyle().PaddingLeft(4).Foreground(lipgloss.Color("#AAAAAA"))
+
+const bioMaxLines = 6
+
+func (p *detailPanel) currentArtist() *artistPage {
+	if len(p.artistStack) == 0 {
+		return nil
+	}
+	return p.artistStack[len(p.artistStack)-1]
+}
+
+// orderedAlbums applies the current sort and grouping
+func (d *detailPanel) orderedAlbums(p *artistPage) []api.Album {
+	sorted, err := artist.SortAlbums(p.albums, artist.SortOrders[d.artistSort])
+	if err != nil || !d.artistGrouped {
+		return sorted
+	}
+	var grouped []api.Album
+	for _, g := range artist.GroupAlbums(sorted) {
+		grouped = append(grouped, g.Albums...)
+	}
+	return grouped
+}
+
+func (d *detailPanel) updateArtist(p *artistPage, msg tea.KeyMsg) tea.Cmd {
+	if p.similarOpen {
+		switch msg.String() {
+		case "up", "k":
+			if p.similarCursor > 0 {
+				p.similarCursor--
+			}
+		case "down", "j":
+			if p.similarCursor < len(p.similar)-1 {
+				p.similarCursor++
+			}
+		case "enter":
+			if len(p.similar) > 0 {
+				return d.startLoading(loadDiscography(models.Value(p.similar[p.similarCursor].Id)))
+			}
+		case "r", "esc":
+			p.similarOpen = false
+		}
+		return nil
+	}
+
+	albums := d.orderedAlbums(p)
+	switch msg.String() {
+	case "up", "k":
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case "down", "j":
+		if p.cursor < len(albums)-1 {
+			p.cursor++
+		}
+	case "enter":
+		if len(albums) > 0 {
+			return d.startLoading(loadAlbum(models.Value(albums[p.cursor].Id)))
+		}
+	case "g":
+		d.artistGrouped = !d.artistGrouped
+		p.cursor = 0
+	case "o":
+		d.artistSort = (d.artistSort + 1) % len(artist.SortOrders)
+		p.cursor = 0
+	case "r":
+		p.similarOpen = true
+		p.err = ""
+		if p.similar == nil && len(models.Value(p.artist.SimilarArtistIds)) > 0 {
+			p.similarLoading = true
+			return loadSimilar(p)
+		}
+	case "esc":
+		d.artistStack = d.artistStack[:len(d.artistStack)-1]
+		d.err = ""
+		if len(d.artistStack) == 0 {
+			return intent(focusMsg(resultsPanelID))
+		}
+	}
+	return nil
+}
+
+func (d *detailPanel) viewArtist(p *artistPage, width, height int) string {
+	a := p.artist
+	out := []string{
+		panelTitleStyle.Render(models.Value(a.Name)),
+		itemStyle.Render(fmt.Sprintf("%d albums · %d as primary artist",
+			models.Value(a.AlbumsCount), models.Value(a.AlbumsAsPrimaryArtistCount))),
+	}
+	if bio := strings.TrimSpace(models.Value(a.Biography)); bio != "" {
+		bioLines := strings.Split(bioStyle.Width(max(width, 20)).Render(bio), "\n")
+		if len(bioLines) > bioMaxLines {
+			bioLines = append(bioLines[:bioMaxLines], bioStyle.Render("…"))
+		}
+		out = append(out, "")
+		out = append(out, bioLines...)
+	}
+	out = append(out, "")
+
+	var rows []string
+	cursorRow := 0
+	if p.similarOpen {
+		out = append(out, panelTitleStyle.Render("Similar artists"))
+		switch {
+		case p.similarLoading:
+			out = append(out, itemStyle.Render("Loading..."))
+		case p.err != "":
+			out = append(out, "[ERROR] "+p.err)
+		case len(p.similar) == 0:
+			out = append(out, itemStyle.Render("No similar artists."))
+		}
+		for i, sim := range p.similar {
+			rows = append(rows, listLine(models.Value(sim.Name), p.similarCursor == i))
+		}
+		cursorRow = p.similarCursor
+	} else {
+		albums := d.orderedAlbums(p)
+		if len(albums) == 0 {
+			out = append(out, "No albums found.")
+		}
+		lastType := ""
+		for i, al := range albums {
+			if d.artistGrouped && artist.AlbumType(al) != lastType {
+				lastType = artist.AlbumType(al)
+				rows = append(rows, panelTitleStyle.Render(lastType+"s"))
+			}
+			if p.cursor == i {
+				cursorRow = len(rows)
+			}
+			line := fmt.Sprintf("%-10s %s (%d tracks)", models.Value(al.ReleaseDate), models.Value(al.Title), models.AlbumTrackCount(al))
+			rows = append(rows, listLine(line, p.cursor == i))
+		}
+	}
+	start, end := window(cursorRow, len(rows), height-len(out))
+	return lines(append(out, rows[start:end]...))
+}
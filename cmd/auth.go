@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/urfave/cli/v2"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/login"
+	"github.com/adityadeshmukh1/dab-cli/internal/output"
+)
+
+func loginCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "login",
+		Usage: "log in and store a session",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "email", Usage: "account email (prompted if omitted)"},
+			&cli.StringFlag{Name: "password", Usage: "account password (prompted if omitted)", EnvVars: []string{"DAB_PASSWORD"}},
+		},
+		Action: func(c *cli.Context) error {
+			email := c.String("email")
+			if email == "" {
+				var err error
+				if email, err = prompt("Email: "); err != nil {
+					return fail(err)
+				}
+			}
+			password := c.String("password")
+			if password == "" {
+				var err error
+				if password, err = promptPassword("Password: "); err != nil {
+					return fail(err)
+				}
+			}
+			if err := login.Login(email, password); err != nil {
+				return fail(err)
+			}
+			fmt.Fprintln(c.App.Writer, "Login Successful!")
+			return nil
+		},
+	}
+}
+
+func logoutCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "logout",
+		Usage: "end the current session",
+		Action: func(c *cli.Context) error {
+			remote, err := login.Logout()
+			if err != nil {
+				return fail(err)
+			}
+			fmt.Fprintln(c.App.Writer, "Logged out.")
+			if remote != nil {
+				fmt.Fprintf(c.App.ErrWriter, "The server was not told, so its session may still be open: %v\n", remote)
+			}
+			return nil
+		},
+	}
+}
+
+func whoamiCommand() *cli.Command {
+	return withFormat(&cli.Command{
+		Name:  "whoami",
+		Usage: "show the logged-in user",
+		Action: func(c *cli.Context) error {
+			user, err := login.WhoAmI()
+			if err != nil {
+				return fail(err)
+			}
+			if user == nil {
+				return cli.Exit("Not logged in.", exitAuth)
+			}
+			return render(c, output.User(*user))
+		},
+	})
+}
+
+// stdin is shared by the prompts: a reader of its own would buffer the
+// next answer piped in along with this one, and drop it
+var stdin = bufio.NewReader(os.Stdin)
+
+func prompt(label string) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read input: %v", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// promptPassword reads without echo when stdin is a terminal
+func promptPassword(label string) (string, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return prompt(label)
+	}
+	fmt.Fprint(os.Stderr, label)
+	data, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %v", err)
+	}
+	return string(data), nil
+}
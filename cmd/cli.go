@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/client"
+	"github.com/adityadeshmukh1/dab-cli/internal/config"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+	"github.com/adityadeshmukh1/dab-cli/internal/output"
+	"github.com/adityadeshmukh1/dab-cli/internal/pager"
+)
+
+// Exit codes returned by Run
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitUsage    = 2
+	exitAuth     = 3
+	exitNotFound = 4
+	exitUnsynced = 5 // done locally, but the server did not take it
+)
+
+// NewApp builds the dab command tree. Running it without a subcommand
+// launches the TUI.
+func NewApp() *cli.App {
+	app := &cli.App{
+		Name:  "dab",
+		Usage: "terminal client for the DAB Music API",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "config", Usage: "path to config file", EnvVars: []string{"DAB_CONFIG"}},
+			&cli.StringFlag{Name: "profile", Usage: "server profile to use", EnvVars: []string{"DAB_PROFILE"}},
+			&cli.StringFlag{Name: "base-url", Usage: "API base URL, overriding the profile", EnvVars: []string{"DAB_BASE_URL"}},
+		},
+		Before: func(c *cli.Context) error {
+			err := config.Init(config.Overrides{
+				ConfigPath: c.String("config"),
+				Profile:    c.String("profile"),
+				BaseURL:    c.String("base-url"),
+			})
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("Error loading config: %v", err), exitUsage)
+			}
+			return nil
+		},
+		OnUsageError: usageError,
+		Action: func(c *cli.Context) error {
+			RunTUI()
+			return nil
+		},
+		Commands: []*cli.Command{
+			searchCommand(),
+			playCommand(),
+			downloadCommand(),
+			albumCommand(),
+			artistCommand(),
+			lyricsCommand(),
+			loginCommand(),
+			logoutCommand(),
+			whoamiCommand(),
+			favCommand(),
+			libCommand(),
+			queueCommand(),
+			{
+				Name:   "tui",
+				Usage:  "launch the interactive interface",
+				Action: func(c *cli.Context) error { RunTUI(); return nil },
+			},
+			mockServerCommand(),
+		},
+	}
+	setUsageError(app.Commands)
+	return app
+}
+
+// urfave/cli only applies OnUsageError per command, so wire it everywhere
+func setUsageError(cmds []*cli.Command) {
+	for _, c := range cmds {
+		c.OnUsageError = usageError
+		setUsageError(c.Subcommands)
+	}
+}
+
+// Run executes the CLI and returns the process exit code
+func Run(args []string) int {
+	app := NewApp()
+	app.ExitErrHandler = func(*cli.Context, error) {} // exit codes are handled below
+
+	err := app.Run(args)
+	if err == nil {
+		return exitOK
+	}
+	fmt.Fprintln(os.Stderr, err)
+
+	var exitErr cli.ExitCoder
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return exitError
+}
+
+func usageError(c *cli.Context, err error, isSubcommand bool) error {
+	return cli.Exit(fmt.Sprintf("Usage error: %v", err), exitUsage)
+}
+
+// fail maps an error to an exit code, so scripts can tell a missing
+// login or an unknown ID from other failures
+func fail(err error) error {
+	if err == nil {
+		return nil
+	}
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.Status {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return cli.Exit(err.Error(), exitAuth)
+		case http.StatusNotFound:
+			return cli.Exit(err.Error(), exitNotFound)
+		}
+	}
+	return cli.Exit(err.Error(), exitError)
+}
+
+// needArgs rejects commands called with too few positional arguments
+func needArgs(c *cli.Context, n int, usage string) error {
+	if c.NArg() < n {
+		return cli.Exit(fmt.Sprintf("Usage: dab %s %s", c.Command.Name, usage), exitUsage)
+	}
+	return nil
+}
+
+// withFormat gives a command the --format flag, validated before the
+// command talks to the API
+func withFormat(cmd *cli.Command) *cli.Command {
+	cmd.Flags = append(cmd.Flags, &cli.StringFlag{
+		Name:    "format",
+		Aliases: []string{"o"},
+		Value:   "text",
+		Usage:   "text, json, ndjson, csv, tsv or a Go template such as '{{.Title}}'",
+		EnvVars: []string{"DAB_FORMAT"},
+	})
+	cmd.Before = func(c *cli.Context) error {
+		if _, err := output.New(c.String("format")); err != nil {
+			return cli.Exit(err.Error(), exitUsage)
+		}
+		return nil
+	}
+	return cmd
+}
+
+// render prints a result in the command's --format
+func render(c *cli.Context, r output.Result) error {
+	p, err := output.New(c.String("format"))
+	if err != nil {
+		return cli.Exit(err.Error(), exitUsage)
+	}
+	return fail(p.Print(c.App.Writer, r))
+}
+
+// withPaging gives a command that lists something paged --page, --limit
+// and --all
+func withPaging(cmd *cli.Command) *cli.Command {
+	cmd.Flags = append(cmd.Flags,
+		&cli.IntFlag{Name: "page", Value: 1, Usage: "page to show, counting from 1"},
+		&cli.IntFlag{Name: "limit", Aliases: []string{"n"}, Usage: fmt.Sprintf("items per page (default %d)", pager.DefaultLimit)},
+		&cli.BoolFlag{Name: "all", Usage: "fetch every page"},
+	)
+	return cmd
+}
+
+// paging reads the flags withPaging adds
+func paging(c *cli.Context) (page, limit int, all bool, err error) {
+	page, limit, all = c.Int("page"), c.Int("limit"), c.Bool("all")
+	switch {
+	case page < 1:
+		return 0, 0, false, cli.Exit("--page counts from 1", exitUsage)
+	case limit < 0:
+		return 0, 0, false, cli.Exit("--limit can't be negative", exitUsage)
+	case all && c.IsSet("page"):
+		return 0, 0, false, cli.Exit("--all and --page don't go together", exitUsage)
+	}
+	return page, limit, all, nil
+}
+
+// fetchPages loads what the paging flags ask for: one page, or with --all
+// every page as one
+func fetchPages[T any](c *cli.Context, fetch pager.Fetch[T]) (pager.Page[T], error) {
+	page, limit, all, err := paging(c)
+	if err != nil {
+		return pager.Page[T]{}, err
+	}
+	p := pager.New(fetch, limit)
+	if !all {
+		pg, err := p.Fetch(context.Background(), page)
+		return pg, fail(err)
+	}
+	items, err := p.All(context.Background())
+	if err != nil {
+		return pager.Page[T]{}, fail(err)
+	}
+	return pager.Single(items), nil
+}
+
+// listPages fetches what the paging flags ask for and renders it. result
+// gets the position of the first item in the whole list, counting from 1.
+func listPages[T any](c *cli.Context, fetch pager.Fetch[T], result func(items []T, first int) output.Result) error {
+	pg, err := fetchPages(c, fetch)
+	if err != nil {
+		return err
+	}
+	if err := render(c, result(pg.Items, firstOf(pg))); err != nil {
+		return err
+	}
+	pageHint(c, pg)
+	return nil
+}
+
+// firstOf is the position of a page's first item in the whole list
+func firstOf[T any](pg pager.Page[T]) int {
+	return (pg.Number-1)*models.Value(pg.Pagination.Limit) + 1
+}
+
+// pageHint tells, on stderr, which part of the list a page holds and how
+// to get the rest, when there is more
+func pageHint[T any](c *cli.Context, pg pager.Page[T]) {
+	if !models.Value(pg.Pagination.HasMore) || len(pg.Items) == 0 {
+		return
+	}
+	if p, err := output.New(c.String("format")); err != nil || !p.IsText() {
+		return
+	}
+	first := firstOf(pg)
+	of := ""
+	if total := pg.Pagination.Total; total != nil {
+		of = fmt.Sprintf(" of %d", *total)
+	}
+	fmt.Fprintf(c.App.ErrWriter, "Showing %d-%d%s; more with --page %d or --all.\n",
+		first, first+len(pg.Items)-1, of, pg.Number+1)
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/album"
+	"github.com/adityadeshmukh1/dab-cli/internal/artist"
+	"github.com/adityadeshmukh1/dab-cli/internal/favorites"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+)
+
+type albumLoadedMsg struct {
+	album *api.Album
+	err   error
+}
+
+func loadAlbum(id string) tea.Cmd {
+	return func() tea.Msg {
+		a, err := album.Get(id)
+		return albumLoadedMsg{album: a, err: err}
+	}
+}
+
+type discographyMsg struct {
+	artist *api.Artist
+	albums []api.Album
+	err    error
+}
+
+func loadDiscography(id string) tea.Cmd {
+	return func() tea.Msg {
+		ar, albums, err := artist.Discography(id)
+		return discographyMsg{artist: ar, albums: albums, err: err}
+	}
+}
+
+// detailPanel drills down into albums and artists. An album opened from
+// an artist page returns to that page on Esc.
+type detailPanel struct {
+	np   *nowPlaying
+	favs *favorites.Cache
+
+	album         *api.Album
+	albumCursor   int
+	picks         picks // of the album's tracks
+	artistStack   []*artistPage
+	artistSort    int // index into artist.SortOrders
+	artistGrouped bool
+	loading       bool
+	err           string
+	spinner       spinner.Model
+}
+
+func newDetailPanel(np *nowPlaying, favs *favorites.Cache) *detailPanel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	return &detailPanel{np: np, favs: favs, spinner: s}
+}
+
+func (p *detailPanel) title() string {
+	switch {
+	case p.album != nil:
+		return "Album"
+	case len(p.artistStack) > 0:
+		return "Artist"
+	}
+	return "Detail"
+}
+
+func (p *detailPanel) typing() bool { return false }
+
+func (p *detailPanel) help() string {
+	switch {
+	case p.album != nil:
+		return "Enter play track · p play album · a queue album · n play album next · d download album · f favorite · v mark · l add to library · Esc back"
+	case len(p.artistStack) > 0:
+		if p.currentArtist().similarOpen {
+			return "Enter open artist · r/Esc back to albums"
+		}
+		return fmt.Sprintf("Enter open album · g group by type · o sort (%s) · r similar artists · Esc back",
+			artist.SortOrders[p.artistSort])
+	}
+	return "Open an album or artist from the results"
+}
+
+// open starts loading an album or artist picked elsewhere, replacing
+// whatever the panel showed
+func (p *detailPanel) open(load tea.Cmd) tea.Cmd {
+	p.album, p.artistStack = nil, nil
+	return tea.Batch(p.startLoading(load), intent(focusMsg(detailPanelID)))
+}
+
+func (p *detailPanel) startLoading(load tea.Cmd) tea.Cmd {
+	p.loading, p.err = true, ""
+	return tea.Batch(p.spinner.Tick, load)
+}
+
+func (p *detailPanel) update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case openAlbumMsg:
+		return p.open(loadAlbum(string(msg)))
+	case openArtistMsg:
+		return p.open(loadDiscography(string(msg)))
+
+	case albumLoadedMsg:
+		p.loading = false
+		if msg.err != nil {
+			p.err = msg.err.Error()
+			return nil
+		}
+		p.album, p.albumCursor, p.picks = msg.album, 0, nil
+		return nil
+
+	case discographyMsg:
+		p.loading = false
+		if msg.err != nil {
+			p.err = msg.err.Error()
+			return nil
+		}
+		p.artistStack = append(p.artistStack, &artistPage{artist: *msg.artist, albums: msg.albums})
+		return nil
+
+	case similarMsg:
+		msg.page.similarLoading = false
+		if msg.err != nil {
+			msg.page.err = msg.err.Error()
+		} else {
+			msg.page.similar = msg.artists
+		}
+		return nil
+
+	case spinner.TickMsg:
+		if !p.loading {
+			return nil
+		}
+		var cmd tea.Cmd
+		p.spinner, cmd = p.spinner.Update(msg)
+		return cmd
+
+	case tea.KeyMsg:
+		switch {
+		case p.loading:
+			return nil
+		case p.album != nil:
+			return p.updateAlbum(msg)
+		case len(p.artistStack) > 0:
+			return p.updateArtist(p.currentArtist(), msg)
+		}
+		if msg.String() == "esc" {
+			return intent(focusMsg(resultsPanelID))
+		}
+	}
+	return nil
+}
+
+func (p *detailPanel) updateAlbum(msg tea.KeyMsg) tea.Cmd {
+	tracks := models.Value(p.album.Tracks)
+	title := models.Value(p.album.Title)
+	switch msg.String() {
+	case "up", "k":
+		if p.albumCursor > 0 {
+			p.albumCursor--
+		}
+	case "down", "j":
+		if p.albumCursor < len(tracks)-1 {
+			p.albumCursor++
+		}
+	case "enter":
+		if len(tracks) > 0 {
+			return intent(playMsg{tracks: []api.Track{tracks[p.albumCursor]}})
+		}
+	case "p":
+		return intent(playMsg{tracks: tracks})
+	case "a", "n":
+		return intent(enqueueMsg{tracks: tracks, next: msg.String() == "n", what: fmt.Sprintf("%d tracks from %s", len(tracks), title)})
+	case "d":
+		return intent(downloadAlbumMsg(*p.album))
+	case "f":
+		if len(tracks) > 0 {
+			return intent(toggleFavoriteMsg(tracks[p.albumCursor]))
+		}
+	case "v":
+		if len(tracks) > 0 {
+			p.picks.toggle(tracks[p.albumCursor])
+		}
+	case "l":
+		return p.picks.addToLibrary(tracks, p.albumCursor)
+	case "esc":
+		p.album = nil
+		if len(p.artistStack) == 0 {
+			return intent(focusMsg(resultsPanelID))
+		}
+	}
+	return nil
+}
+
+func (p *detailPanel) view(width, height int) string {
+	if p.loading {
+		return "Loading " + p.spinner.View()
+	}
+	var s string
+	if p.err != "" {
+		s = "[ERROR] " + p.err + "\n"
+		height--
+	}
+	switch {
+	case p.album != nil:
+		return s + p.viewAlbum(width, height)
+	case len(p.artistStack) > 0:
+		return s + p.viewArtist(p.currentArtist(), width, height)
+	}
+	return s + itemStyle.Render("Nothing open.")
+}
+
+func (p *detailPanel) viewAlbum(width, height int) string {
+	a := *p.album
+	out := []string{
+		panelTitleStyle.Render(fmt.Sprintf("%s - %s", models.Value(a.Title), models.Value(a.Artist))),
+		itemStyle.Render(fmt.Sprintf("Released %s · %s · %s",
+			models.Value(a.ReleaseDate), models.Value(a.Label), models.Value(a.Genre))),
+		itemStyle.Render(fmt.Sprintf("%d tracks · %s · %s · UPC %s",
+			models.AlbumTrackCount(a), models.Duration(models.AlbumDuration(a)),
+			models.Quality(a.AudioQuality), models.Value(a.Upc))),
+		"",
+	}
+	tracks := models.Value(a.Tracks)
+	start, end := window(p.albumCursor, len(tracks), height-len(out))
+	for i := start; i < end; i++ {
+		t := tracks[i]
+		line := fmt.Sprintf("%2d. %-40s %6s%s", i+1, models.Value(t.Title), models.Duration(models.Value(t.Duration)), heart(p.favs, t)+p.picks.mark(t))
+		out = append(out, p.np.trackLine(t, line, p.albumCursor == i))
+	}
+	return lines(out)
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/download"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+)
+
+// downloadEventMsg wakes the TUI when the download manager reports a change
+type downloadEventMsg download.Event
+
+// waitForDownload delivers the manager's next event. Update re-issues it
+// after every event, so exactly one is pending at a time.
+func waitForDownload(mgr *download.Manager) tea.Cmd {
+	if mgr == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return downloadEventMsg(<-mgr.Events())
+	}
+}
+
+const progressWidth = 20
+
+// progressBar draws a fixed-width bar, or dots when the size is unknown
+func progressBar(written, total int64) string {
+	if total <= 0 {
+		return strings.Repeat("·", progressWidth)
+	}
+	filled := int(written * progressWidth / total)
+	if filled > progressWidth {
+		filled = progressWidth
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", progressWidth-filled)
+}
+
+// downloadsPanel shows the download manager's jobs
+type downloadsPanel struct {
+	mgr    *download.Manager // nil if it failed to start
+	err    string
+	jobs   []download.Job
+	cursor int
+}
+
+func newDownloadsPanel(mgr *download.Manager, err string) *downloadsPanel {
+	p := &downloadsPanel{mgr: mgr, err: err}
+	if mgr != nil {
+		p.jobs = mgr.Jobs()
+	}
+	return p
+}
+
+func (p *downloadsPanel) title() string { return "Downloads" }
+func (p *downloadsPanel) typing() bool  { return false }
+func (p *downloadsPanel) help() string  { return "r retry failed · c clear finished" }
+
+func (p *downloadsPanel) update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case downloadEventMsg:
+		p.jobs = p.mgr.Jobs()
+		if p.cursor >= len(p.jobs) {
+			p.cursor = max(len(p.jobs)-1, 0)
+		}
+		return waitForDownload(p.mgr)
+
+	case downloadTrackMsg:
+		if p.mgr == nil {
+			return status("", fmt.Errorf("downloads unavailable: %s", p.err))
+		}
+		t := api.Track(msg)
+		return status(fmt.Sprintf("Downloading %s (see Downloads).", models.Value(t.Title)), p.mgr.AddTrack(t))
+
+	case downloadAlbumMsg:
+		if p.mgr == nil {
+			return status("", fmt.Errorf("downloads unavailable: %s", p.err))
+		}
+		id, mgr := models.Value(msg.Id), p.mgr
+		done := fmt.Sprintf("Downloading %d tracks from %s (see Downloads).",
+			models.AlbumTrackCount(api.Album(msg)), models.Value(msg.Title))
+		return tea.Batch(status("Queueing download...", nil), action(done, func() error {
+			_, err := mgr.AddAlbum(id)
+			return err
+		}))
+
+	case tea.KeyMsg:
+		if p.mgr == nil {
+			return nil
+		}
+		switch msg.String() {
+		case "up", "k":
+			if p.cursor > 0 {
+				p.cursor--
+			}
+		case "down", "j":
+			if p.cursor < len(p.jobs)-1 {
+				p.cursor++
+			}
+		case "r":
+			if p.cursor < len(p.jobs) {
+				p.mgr.Retry(p.jobs[p.cursor].ID)
+			}
+		case "c":
+			p.mgr.ClearFinished()
+			p.jobs = p.mgr.Jobs()
+			p.cursor = 0
+		}
+	}
+	return nil
+}
+
+func (p *downloadsPanel) view(width, height int) string {
+	if p.err != "" {
+		return "[ERROR] " + p.err
+	}
+
+	counts := map[string]int{}
+	for _, j := range p.jobs {
+		counts[j.State]++
+	}
+	out := []string{itemStyle.Render(fmt.Sprintf("%d running · %d queued · %d failed · %d done",
+		counts[download.Running], counts[download.Queued], counts[download.Failed], counts[download.Done]))}
+	if len(p.jobs) == 0 {
+		out = append(out, itemStyle.Render("Nothing downloading. Press d on an album or pick Download on a track."))
+	}
+
+	start, end := window(p.cursor, len(p.jobs), height-len(out))
+	for i := start; i < end; i++ {
+		j := p.jobs[i]
+		var status string
+		switch j.State {
+		case download.Running:
+			status = progressBar(j.Written, j.Total)
+			if j.Total > 0 {
+				status += fmt.Sprintf(" %3d%% of %s", j.Written*100/j.Total, models.Bytes(j.Total))
+			}
+		case download.Failed:
+			status = "failed: " + j.Err
+			if !j.RetryAt.IsZero() {
+				status += fmt.Sprintf(" (retrying in %ds)", int(time.Until(j.RetryAt).Seconds()+1))
+			}
+		case download.Done:
+			status = "done"
+			if j.Result.Skipped {
+				status = "already downloaded"
+			} else if j.Result.TagErr != nil {
+				status = "done, not tagged: " + j.Result.TagErr.Error()
+			}
+		default:
+			status = j.State
+		}
+		title := j.Title
+		if j.Profile != "" {
+			title += " [" + j.Profile + "]"
+		}
+		out = append(out, listLine(fmt.Sprintf("%-30.30s %s", title, status), p.cursor == i))
+	}
+	return lines(out)
+}
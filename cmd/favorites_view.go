@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/favorites"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+)
+
+type favoritesLoadedMsg struct {
+	tracks []api.Track
+	err    error
+}
+
+func loadFavorites() tea.Cmd {
+	return func() tea.Msg {
+		tracks, err := favorites.List()
+		return favoritesLoadedMsg{tracks: tracks, err: err}
+	}
+}
+
+// favoriteDoneMsg reports the server's answer to a toggle already shown
+type favoriteDoneMsg struct {
+	track api.Track
+	on    bool // whether it was added
+	err   error
+}
+
+// heart marks a favorite in a track row
+func heart(favs *favorites.Cache, t api.Track) string {
+	if favs.Has(models.Value(t.Id)) {
+		return " ♥"
+	}
+	return ""
+}
+
+// favoritesPanel lists the favorite tracks and owns the cache the hearts
+// in other lists come from. Toggles show at once and are undone if the
+// server refuses them; the list is refreshed from the server at start,
+// after a login and on R.
+type favoritesPanel struct {
+	np   *nowPlaying
+	favs *favorites.Cache
+
+	loading bool
+	err     string
+	cursor  int
+	picks   picks
+}
+
+func newFavoritesPanel(np *nowPlaying, favs *favorites.Cache, err string) *favoritesPanel {
+	return &favoritesPanel{np: np, favs: favs, loading: true, err: err}
+}
+
+func (p *favoritesPanel) title() string { return "Favorites" }
+func (p *favoritesPanel) typing() bool  { return false }
+
+func (p *favoritesPanel) help() string {
+	return "Enter play from here · a queue · n play next · f unfavorite · v mark · l add to library · R reload"
+}
+
+func (p *favoritesPanel) load() tea.Cmd {
+	p.loading, p.err = true, ""
+	return loadFavorites()
+}
+
+// toggle flips t in the cache and asks the server to follow
+func (p *favoritesPanel) toggle(t api.Track) tea.Cmd {
+	id := models.Value(t.Id)
+	on := !p.favs.Has(id)
+	var err error
+	if on {
+		err = p.favs.Put(t)
+	} else {
+		err = p.favs.Drop(id)
+	}
+	p.clamp()
+	return tea.Batch(status("", err), func() tea.Msg {
+		if on {
+			return favoriteDoneMsg{track: t, on: on, err: favorites.Add(t)}
+		}
+		return favoriteDoneMsg{track: t, on: on, err: favorites.Remove(id)}
+	})
+}
+
+func (p *favoritesPanel) clamp() {
+	p.cursor = min(p.cursor, max(len(p.favs.Tracks())-1, 0))
+}
+
+func (p *favoritesPanel) update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case loggedInMsg:
+		return p.load()
+	case favoritesLoadedMsg:
+		p.loading = false
+		if msg.err != nil {
+			p.err = msg.err.Error() // the cached favorites stay
+			return nil
+		}
+		p.err = ""
+		err := p.favs.Set(msg.tracks)
+		p.clamp()
+		return status("", err)
+
+	case toggleFavoriteMsg:
+		return p.toggle(api.Track(msg))
+	case favoriteDoneMsg:
+		name := models.Value(msg.track.Title)
+		if msg.err != nil {
+			// Undo what the cache shows
+			var err error
+			if msg.on {
+				err = p.favs.Drop(models.Value(msg.track.Id))
+			} else {
+				err = p.favs.Put(msg.track)
+			}
+			p.clamp()
+			if err != nil {
+				return status("", fmt.Errorf("favorite %s: %v; undoing it here: %v", name, msg.err, err))
+			}
+			return status("", fmt.Errorf("favorite %s: %v", name, msg.err))
+		}
+		if msg.on {
+			return status(fmt.Sprintf("Added %s to favorites.", name), nil)
+		}
+		return status(fmt.Sprintf("Removed %s from favorites.", name), nil)
+
+	case tea.KeyMsg:
+		tracks := p.favs.Tracks()
+		switch msg.String() {
+		case "up", "k":
+			if p.cursor > 0 {
+				p.cursor--
+			}
+		case "down", "j":
+			if p.cursor < len(tracks)-1 {
+				p.cursor++
+			}
+		case "enter":
+			if len(tracks) > 0 {
+				return intent(playMsg{tracks: tracks, index: p.cursor})
+			}
+		case "a", "n":
+			if len(tracks) > 0 {
+				t := tracks[p.cursor]
+				return intent(enqueueMsg{tracks: []api.Track{t}, next: msg.String() == "n", what: models.Value(t.Title)})
+			}
+		case "f":
+			if len(tracks) > 0 {
+				return p.toggle(tracks[p.cursor])
+			}
+		case "v":
+			if len(tracks) > 0 {
+				p.picks.toggle(tracks[p.cursor])
+			}
+		case "l":
+			return p.picks.addToLibrary(tracks, p.cursor)
+		case "R":
+			return p.load()
+		}
+	}
+	return nil
+}
+
+func (p *favoritesPanel) view(width, height int) string {
+	tracks := p.favs.Tracks()
+	var out []string
+	switch {
+	case p.err != "":
+		out = append(out, "[ERROR] "+p.err)
+	case p.loading && len(tracks) == 0:
+		return "Loading favorites..."
+	case len(tracks) == 0:
+		return itemStyle.Render("No favorites yet. Press f on a track to add one.")
+	}
+	label := countLabel(len(tracks), "track")
+	if p.loading {
+		label += " · refreshing..."
+	}
+	out = append(out, itemStyle.Render(label))
+	start, end := window(p.cursor, len(tracks), height-len(out))
+	for i := start; i < end; i++ {
+		t := tracks[i]
+		line := fmt.Sprintf("%2d. %s - %s%s", i+1, models.Value(t.Title), models.Value(t.Artist), p.picks.mark(t))
+		out = append(out, p.np.trackLine(t, line, p.cursor == i))
+	}
+	return lines(out)
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Layout sizes, in terminal cells
+const (
+	defaultWidth  = 100 // until the first WindowSizeMsg
+	defaultHeight = 32
+
+	searchHeight     = 3 // the search bar, with its border
+	nowPlayingHeight = 6
+	footerHeight     = 2 // status and help lines
+
+	// narrowWidth is below which the middle shows one panel, not two
+	narrowWidth = 80
+
+	defaultSplit = 60 // percent of the width for the left slot
+	minSplit     = 30
+	maxSplit     = 80
+	splitStep    = 5
+)
+
+// slot is a place in the layout. Several panels share the left and right
+// slots; each shows whichever of its panels had focus last.
+type slot int
+
+const (
+	topSlot slot = iota
+	leftSlot
+	rightSlot
+	bottomSlot
+)
+
+// focus tracks the focused panel and what each shared slot shows
+type focus struct {
+	current     panelID
+	left, right panelID
+}
+
+func newFocus() focus {
+	return focus{current: searchPanelID, left: resultsPanelID, right: queuePanelID}
+}
+
+// set focuses a panel, bringing it into its slot
+func (f *focus) set(id panelID) {
+	f.current = id
+	switch panelSlots[id] {
+	case leftSlot:
+		f.left = id
+	case rightSlot:
+		f.right = id
+	}
+}
+
+// cycle moves focus step panels along the tab order
+func (f *focus) cycle(step int) {
+	n := int(panelCount)
+	f.set(panelID(((int(f.current)+step)%n + n) % n))
+}
+
+// box draws a panel with its title and a border that lights up when
+// focused. Content beyond the box is cut off.
+func box(title, content string, width, height int, focused bool) string {
+	border := panelStyle
+	if focused {
+		border = focusedPanelStyle
+	}
+	innerW, innerH := max(width-4, 1), max(height-2, 1)
+	if title != "" {
+		content = title + "\n" + content
+	}
+	content = lipgloss.NewStyle().MaxWidth(innerW).MaxHeight(innerH).Render(content)
+	return border.Width(width - 2).Height(innerH).Render(content)
+}
+
+// tabs renders the titles of the panels sharing a slot, the shown one
+// highlighted
+func tabs(panels []panel, ids []panelID, shown panelID) string {
+	var titles []string
+	for _, id := range ids {
+		if id == shown {
+			titles = append(titles, panelTitleStyle.Render(panels[id].title()))
+		} else {
+			titles = append(titles, tabStyle.Render(panels[id].title()))
+		}
+	}
+	return strings.Join(titles, tabStyle.Render(" · "))
+}
+
+// window returns the rows [start, end) of n to show in height lines,
+// keeping the cursor in view
+func window(cursor, n, height int) (start, end int) {
+	if height < 1 {
+		height = 1
+	}
+	if n <= height {
+		return 0, n
+	}
+	start = cursor - height/2
+	start = max(min(start, n-height), 0)
+	return start, start + height
+}
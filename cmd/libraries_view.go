@@ -0,0 +1,507 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/favorites"
+	"github.com/adityadeshmukh1/dab-cli/internal/library"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+	"github.com/adityadeshmukh1/dab-cli/internal/pager"
+)
+
+type librariesLoadedMsg struct {
+	libs []api.Library
+	err  error
+}
+
+func loadLibraries() tea.Cmd {
+	return func() tea.Msg {
+		libs, err := library.List()
+		return librariesLoadedMsg{libs: libs, err: err}
+	}
+}
+
+type libraryLoadedMsg struct {
+	id  string
+	lib *library.Library
+	err error
+}
+
+// loadLibrary fetches a library with the first page of its tracks
+func loadLibrary(id string) tea.Cmd {
+	return func() tea.Msg {
+		lib, err := library.Get(id, 1, pager.DefaultLimit)
+		return libraryLoadedMsg{id: id, lib: lib, err: err}
+	}
+}
+
+// libraryEditedMsg reports a change made to a library in the background
+type libraryEditedMsg struct {
+	done string
+	err  error
+}
+
+// editLibrary runs fn in the background; the panel reloads once it is done
+func editLibrary(done string, fn func() error) tea.Cmd {
+	return func() tea.Msg {
+		return libraryEditedMsg{done: done, err: fn()}
+	}
+}
+
+// libraryPrompt is a one-line field for a library's name or description
+type libraryPrompt struct {
+	label    string
+	text     string
+	optional bool // whether it may be left empty
+	submit   func(text string) tea.Cmd
+}
+
+// librariesPanel lists the libraries, loaded when first shown, and the
+// tracks of the one opened, a page at a time. It also creates and edits
+// them, and asks which one to add tracks to for the other panels.
+type librariesPanel struct {
+	np   *nowPlaying
+	favs *favorites.Cache
+
+	libs    []api.Library
+	loaded  bool
+	loading bool
+	opening string // ID of the library being opened
+	err     string
+	cursor  int
+
+	open   *library.Library
+	tracks *pagedList[api.Track]
+	picks  picks
+
+	prompt     *libraryPrompt // open while typing a name or description
+	deleting   bool           // asking to confirm deleting the current library
+	adding     []api.Track    // to add to the library picked; nil when not picking
+	addingWhat string
+}
+
+func newLibrariesPanel(np *nowPlaying, favs *favorites.Cache) *librariesPanel {
+	return &librariesPanel{np: np, favs: favs, tracks: newPagedList[api.Track]()}
+}
+
+func (p *librariesPanel) title() string { return "Libraries" }
+func (p *librariesPanel) typing() bool  { return p.prompt != nil }
+
+func (p *librariesPanel) help() string {
+	switch {
+	case p.prompt != nil:
+		return "Enter save · Esc cancel"
+	case p.deleting:
+		return "y delete · n keep"
+	case p.adding != nil:
+		return "Enter add here · c new library · Esc cancel"
+	case p.open != nil:
+		return "Enter play from here · p play all · a queue all · n play all next · x remove · f favorite · v mark · l add to library · r rename · e describe · P public/private · D delete · Esc back"
+	}
+	return "Enter open · p play · a queue · n play next · c create · r rename · e describe · P public/private · D delete · R reload"
+}
+
+func (p *librariesPanel) load() tea.Cmd {
+	p.loading, p.err = true, ""
+	return loadLibraries()
+}
+
+// refresh reloads the list and the library open after a change, keeping
+// what is shown until the answers arrive
+func (p *librariesPanel) refresh() tea.Cmd {
+	cmds := []tea.Cmd{loadLibraries()}
+	if p.open != nil {
+		cmds = append(cmds, loadLibrary(models.Value(p.open.Id)))
+	}
+	return tea.Batch(cmds...)
+}
+
+// current is the library open, or else the one under the cursor
+func (p *librariesPanel) current() (api.Library, bool) {
+	if p.open != nil && p.adding == nil {
+		return p.open.Library, true
+	}
+	if len(p.libs) == 0 {
+		return api.Library{}, false
+	}
+	return p.libs[p.cursor], true
+}
+
+func (p *librariesPanel) update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case focusMsg:
+		if panelID(msg) == librariesPanelID && !p.loaded && !p.loading {
+			return p.load()
+		}
+	case loggedInMsg:
+		if p.loaded {
+			p.close()
+			return p.load()
+		}
+	case librariesLoadedMsg:
+		p.loading, p.loaded = false, true
+		if msg.err != nil {
+			p.err = msg.err.Error()
+			return nil
+		}
+		p.libs = msg.libs
+		p.cursor = min(p.cursor, max(len(p.libs)-1, 0))
+	case libraryLoadedMsg:
+		opened := msg.id == p.opening
+		if !opened && (p.open == nil || models.Value(p.open.Id) != msg.id) {
+			return nil // a refresh of a library closed since
+		}
+		if opened {
+			p.opening = ""
+		}
+		if msg.err != nil {
+			if opened {
+				p.err = msg.err.Error()
+			}
+			return status("", msg.err)
+		}
+		cursor := 0
+		if !opened {
+			cursor = p.tracks.cursor // refreshed, so stay on the same row
+		}
+		p.open = msg.lib
+		tracks := pager.New(library.Tracks(models.Value(msg.lib.Id)), pager.DefaultLimit)
+		tracks.Add(msg.lib.Page())
+		cmd := p.tracks.reset(tracks)
+		p.tracks.cursor = min(cursor, max(p.tracks.len()-1, 0))
+		return cmd
+	case libraryEditedMsg:
+		if msg.err != nil {
+			return status("", msg.err)
+		}
+		return tea.Batch(status(msg.done, nil), p.refresh())
+	case pageMsg[api.Track]:
+		return p.tracks.update(msg)
+
+	case addToLibraryMsg:
+		p.adding, p.addingWhat = msg.tracks, msg.what
+		p.prompt, p.deleting = nil, false
+		return intent(focusMsg(librariesPanelID))
+
+	case tea.KeyMsg:
+		switch {
+		case p.prompt != nil:
+			return p.updatePrompt(msg)
+		case p.deleting:
+			return p.updateDelete(msg)
+		case p.loading, p.opening != "":
+			return nil
+		case p.adding != nil:
+			return p.updatePicker(msg)
+		case p.open != nil:
+			return p.updateLibrary(msg)
+		}
+		return p.updateList(msg)
+	}
+	return nil
+}
+
+func (p *librariesPanel) updateList(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "up", "k":
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case "down", "j":
+		if p.cursor < len(p.libs)-1 {
+			p.cursor++
+		}
+	case "enter":
+		if len(p.libs) > 0 {
+			p.opening, p.err = models.Value(p.libs[p.cursor].Id), ""
+			return loadLibrary(p.opening)
+		}
+	case "p", "a", "n":
+		if len(p.libs) == 0 {
+			return nil
+		}
+		if msg.String() == "p" {
+			return p.playAll(p.libs[p.cursor], 0)
+		}
+		return p.queueAll(p.libs[p.cursor], msg.String() == "n")
+	case "R":
+		return p.load()
+	default:
+		cmd, _ := p.editKey(msg.String())
+		return cmd
+	}
+	return nil
+}
+
+// close goes back from a library to the list
+func (p *librariesPanel) close() {
+	p.open = nil
+	p.tracks.clear()
+	p.picks = nil
+}
+
+func (p *librariesPanel) updateLibrary(msg tea.KeyMsg) tea.Cmd {
+	tracks := p.tracks.items
+	switch msg.String() {
+	case "enter":
+		if len(tracks) > 0 {
+			return p.playAll(p.open.Library, p.tracks.cursor)
+		}
+	case "p":
+		return p.playAll(p.open.Library, 0)
+	case "a", "n":
+		return p.queueAll(p.open.Library, msg.String() == "n")
+	case "f":
+		if len(tracks) > 0 {
+			return intent(toggleFavoriteMsg(p.tracks.selected()))
+		}
+	case "x", "delete":
+		if len(tracks) > 0 {
+			t, lib := p.tracks.selected(), p.open.Library
+			return editLibrary(fmt.Sprintf("Removed %s from %s.", models.Value(t.Title), models.Value(lib.Name)), func() error {
+				return library.RemoveTrack(models.Value(lib.Id), models.Value(t.Id))
+			})
+		}
+	case "v":
+		if len(tracks) > 0 {
+			p.picks.toggle(p.tracks.selected())
+		}
+	case "l":
+		return p.picks.addToLibrary(tracks, p.tracks.cursor)
+	case "esc":
+		p.close()
+	default:
+		if cmd, ok := p.editKey(msg.String()); ok {
+			return cmd
+		}
+		return p.tracks.update(msg)
+	}
+	return nil
+}
+
+// editKey handles the keys that create a library or change the current
+// one, and reports whether key was one of them
+func (p *librariesPanel) editKey(key string) (tea.Cmd, bool) {
+	if key == "c" {
+		p.prompt = &libraryPrompt{label: "New library", submit: func(name string) tea.Cmd {
+			return editLibrary(fmt.Sprintf("Created %s.", name), func() error {
+				_, err := library.Create(name, "", false)
+				return err
+			})
+		}}
+		return nil, true
+	}
+
+	lib, ok := p.current()
+	if !ok {
+		return nil, false
+	}
+	id, name := models.Value(lib.Id), models.Value(lib.Name)
+	switch key {
+	case "r":
+		p.prompt = &libraryPrompt{label: "Rename " + name, text: name, submit: func(text string) tea.Cmd {
+			return editLibrary(fmt.Sprintf("Renamed %s to %s.", name, text), func() error {
+				return library.Update(id, api.PatchLibrariesIdJSONRequestBody{Name: &text})
+			})
+		}}
+	case "e":
+		p.prompt = &libraryPrompt{label: "Describe " + name, text: models.Value(lib.Description), optional: true, submit: func(text string) tea.Cmd {
+			return editLibrary(fmt.Sprintf("Updated the description of %s.", name), func() error {
+				return library.Update(id, api.PatchLibrariesIdJSONRequestBody{Description: &text})
+			})
+		}}
+	case "P":
+		public := !models.Value(lib.IsPublic)
+		done := fmt.Sprintf("%s is now private.", name)
+		if public {
+			done = fmt.Sprintf("%s is now public.", name)
+		}
+		return editLibrary(done, func() error {
+			return library.Update(id, api.PatchLibrariesIdJSONRequestBody{IsPublic: &public})
+		}), true
+	case "D":
+		p.deleting = true
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+func (p *librariesPanel) updatePrompt(msg tea.KeyMsg) tea.Cmd {
+	f := p.prompt
+	switch msg.Type {
+	case tea.KeyRunes:
+		f.text += string(msg.Runes)
+	case tea.KeySpace:
+		f.text += " "
+	case tea.KeyBackspace:
+		if len(f.text) > 0 {
+			f.text = f.text[:len(f.text)-1]
+		}
+	case tea.KeyEnter:
+		p.prompt = nil
+		text := strings.TrimSpace(f.text)
+		if text == "" && !f.optional {
+			return status("", fmt.Errorf("a library needs a name"))
+		}
+		return f.submit(text)
+	case tea.KeyEsc:
+		p.prompt = nil
+	}
+	return nil
+}
+
+func (p *librariesPanel) updateDelete(msg tea.KeyMsg) tea.Cmd {
+	p.deleting = false
+	lib, ok := p.current()
+	if msg.String() != "y" || !ok {
+		return nil
+	}
+	id, name := models.Value(lib.Id), models.Value(lib.Name)
+	if p.open != nil && models.Value(p.open.Id) == id {
+		p.close()
+	}
+	return editLibrary(fmt.Sprintf("Deleted %s.", name), func() error {
+		return library.Delete(id)
+	})
+}
+
+func (p *librariesPanel) updatePicker(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "up", "k":
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case "down", "j":
+		if p.cursor < len(p.libs)-1 {
+			p.cursor++
+		}
+	case "enter":
+		if len(p.libs) == 0 {
+			return nil
+		}
+		lib, tracks := p.libs[p.cursor], p.adding
+		p.adding = nil
+		return editLibrary(fmt.Sprintf("Added %s to %s.", p.addingWhat, models.Value(lib.Name)), func() error {
+			for _, t := range tracks {
+				if err := library.AddTrack(models.Value(lib.Id), t); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	case "c":
+		cmd, _ := p.editKey("c")
+		return cmd
+	case "esc":
+		p.adding = nil
+	}
+	return nil
+}
+
+// whole hands every track of lib to then, fetching the pages not loaded
+// yet first
+func (p *librariesPanel) whole(lib api.Library, then func([]api.Track) tea.Msg) tea.Cmd {
+	id, name := models.Value(lib.Id), models.Value(lib.Name)
+	hand := func(tracks []api.Track) tea.Msg {
+		if len(tracks) == 0 {
+			return statusMsg(name + " has no tracks.")
+		}
+		return then(tracks)
+	}
+	if p.open != nil && models.Value(p.open.Id) == id && p.tracks.ready() && !p.tracks.pager.More() {
+		return intent(hand(p.tracks.items))
+	}
+	return tea.Batch(status(fmt.Sprintf("Loading every track of %s...", name), nil), func() tea.Msg {
+		tracks, err := library.All(context.Background(), id)
+		if err != nil {
+			return statusMsg("[ERROR] " + err.Error())
+		}
+		return hand(tracks)
+	})
+}
+
+func (p *librariesPanel) playAll(lib api.Library, index int) tea.Cmd {
+	return p.whole(lib, func(tracks []api.Track) tea.Msg {
+		// Replaces the status saying the tracks are loading
+		playing := statusMsg(fmt.Sprintf("Playing %s.", models.Value(lib.Name)))
+		return tea.BatchMsg{intent(playing), intent(playMsg{tracks: tracks, index: index})}
+	})
+}
+
+func (p *librariesPanel) queueAll(lib api.Library, next bool) tea.Cmd {
+	return p.whole(lib, func(tracks []api.Track) tea.Msg {
+		what := fmt.Sprintf("%s from %s", countLabel(len(tracks), "track"), models.Value(lib.Name))
+		return enqueueMsg{tracks: tracks, next: next, what: what}
+	})
+}
+
+func (p *librariesPanel) view(width, height int) string {
+	// Prompts and questions go above what they are about
+	var top []string
+	switch {
+	case p.prompt != nil:
+		top = append(top, fmt.Sprintf("%s: %s█", p.prompt.label, p.prompt.text), "")
+	case p.deleting:
+		lib, _ := p.current()
+		top = append(top, fmt.Sprintf("Delete %s and its %s? y/n", models.Value(lib.Name),
+			countLabel(models.Value(lib.TrackCount), "track")), "")
+	}
+	if p.adding != nil {
+		top = append(top, panelTitleStyle.Render(fmt.Sprintf("Add %s to which library?", p.addingWhat)))
+	}
+	height -= len(top)
+
+	var body string
+	switch {
+	case p.loading, p.opening != "":
+		body = "Loading..."
+	case p.err != "":
+		body = "[ERROR] " + p.err
+	case p.open != nil && p.adding == nil:
+		body = p.viewLibrary(height)
+	case len(p.libs) == 0:
+		body = itemStyle.Render("No libraries. Press c to create one.")
+	default:
+		body = p.viewList(height)
+	}
+	return lines(append(top, body))
+}
+
+func (p *librariesPanel) viewList(height int) string {
+	var out []string
+	start, end := window(p.cursor, len(p.libs), height)
+	for i := start; i < end; i++ {
+		l := p.libs[i]
+		line := fmt.Sprintf("%-30s %s", models.Value(l.Name), countLabel(models.Value(l.TrackCount), "track"))
+		if models.Value(l.IsPublic) {
+			line += " · public"
+		}
+		out = append(out, listLine(line, p.cursor == i))
+	}
+	return lines(out)
+}
+
+func (p *librariesPanel) viewLibrary(height int) string {
+	l := p.open
+	title := models.Value(l.Name)
+	if models.Value(l.IsPublic) {
+		title += " · public"
+	}
+	out := []string{panelTitleStyle.Render(title)}
+	if d := models.Value(l.Description); d != "" {
+		out = append(out, itemStyle.Render(d))
+	}
+	out = append(out, "")
+	out = append(out, p.tracks.rows(height-len(out)-1, func(i int, t api.Track, selected bool) string {
+		line := fmt.Sprintf("%2d. %s - %s%s%s", i+1, models.Value(t.Title), models.Value(t.Artist), heart(p.favs, t), p.picks.mark(t))
+		return p.np.trackLine(t, line, selected)
+	})...)
+	out = append(out, tabStyle.Render(p.tracks.status()))
+	return lines(out)
+}
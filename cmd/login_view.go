@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/login"
+)
+
+// loginForm asks for the email, then the password, over the panels
+type loginForm struct {
+	email    string
+	password string
+	step     int // 0 = email, 1 = password
+	busy     bool
+	err      string
+}
+
+type loginMsg struct {
+	email string
+	err   error
+}
+
+func doLogin(email, password string) tea.Cmd {
+	return func() tea.Msg {
+		return loginMsg{email: email, err: login.Login(email, password)}
+	}
+}
+
+func (m model) updateLogin(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	f := m.login
+	if f.busy {
+		return m, nil
+	}
+	field := &f.email
+	if f.step == 1 {
+		field = &f.password
+	}
+	switch msg.Type {
+	case tea.KeyRunes:
+		*field += string(msg.Runes)
+	case tea.KeyBackspace:
+		if len(*field) > 0 {
+			*field = (*field)[:len(*field)-1]
+		}
+	case tea.KeyEnter:
+		if f.step == 0 {
+			f.step = 1
+			return m, nil
+		}
+		f.busy, f.err = true, ""
+		return m, doLogin(f.email, f.password)
+	case tea.KeyEsc:
+		m.login = nil
+	}
+	return m, nil
+}
+
+// loggedIn closes the form once the login went through
+func (m model) loggedIn(msg loginMsg) (tea.Model, tea.Cmd) {
+	if m.login == nil {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.login.busy, m.login.err = false, msg.err.Error()
+		m.login.password, m.login.step = "", 1
+		return m, nil
+	}
+	m.login = nil
+	m.status = "Logged in as " + msg.email + "."
+	return m.Update(loggedInMsg{})
+}
+
+func (m model) viewLogin() string {
+	f := m.login
+	s := panelTitleStyle.Render("Login to DAB") + "\n\n"
+	s += fmt.Sprintf("Email:    %s\n", f.email)
+	if f.step == 1 {
+		s += fmt.Sprintf("Password: %s\n", strings.Repeat("*", len(f.password)))
+	}
+	switch {
+	case f.busy:
+		s += "\nLogging in...\n"
+	case f.err != "":
+		s += fmt.Sprintf("\n[ERROR] %s\n", f.err)
+	}
+	s += helpStyle.Render("Enter continue · Backspace delete · Esc cancel")
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center,
+		focusedPanelStyle.Padding(1, 2).Render(s))
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/mock"
+)
+
+func mockServerCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "mock-server",
+		Usage: "serve a fake DAB API for offline development",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "addr", Value: "127.0.0.1:8787", Usage: "address to listen on"},
+			&cli.StringFlag{Name: "seed", Usage: "directory of JSON/YAML fixtures (default: built-in catalogue)"},
+		},
+		Action: func(c *cli.Context) error {
+			return fail(RunMockServer(c.String("addr"), c.String("seed")))
+		},
+	}
+}
+
+// RunMockServer serves the fake DAB API until the process is killed
+func RunMockServer(addr, seedDir string) error {
+	seed, err := loadSeed(seedDir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Mock DAB server listening on http://%s%s\n", addr, mock.BasePath)
+	fmt.Printf("Point the CLI at it with --base-url http://%s%s\n", addr, mock.BasePath)
+	return http.ListenAndServe(addr, mock.New(seed).Handler())
+}
+
+func loadSeed(dir string) (*mock.Seed, error) {
+	if dir == "" {
+		return mock.DefaultSeed()
+	}
+	return mock.LoadSeedDir(dir)
+}
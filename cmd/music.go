@@ -0,0 +1,391 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/album"
+	"github.com/adityadeshmukh1/dab-cli/internal/artist"
+	"github.com/adityadeshmukh1/dab-cli/internal/download"
+	"github.com/adityadeshmukh1/dab-cli/internal/favorites"
+	"github.com/adityadeshmukh1/dab-cli/internal/lyrics"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+	"github.com/adityadeshmukh1/dab-cli/internal/output"
+	"github.com/adityadeshmukh1/dab-cli/internal/play"
+	"github.com/adityadeshmukh1/dab-cli/internal/search"
+	"github.com/adityadeshmukh1/dab-cli/internal/transcode"
+)
+
+func searchCommand() *cli.Command {
+	return withPaging(withFormat(&cli.Command{
+		Name:      "search",
+		Usage:     "search for tracks, albums or artists",
+		ArgsUsage: "<query>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "type", Aliases: []string{"t"}, Value: "track", Usage: "track, album or artist"},
+		},
+		Action: func(c *cli.Context) error {
+			if err := needArgs(c, 1, "<query>"); err != nil {
+				return err
+			}
+			kind, err := search.ParseKind(c.String("type"))
+			if err != nil {
+				return cli.Exit(err.Error(), exitUsage)
+			}
+			query := strings.Join(c.Args().Slice(), " ")
+			switch kind {
+			case api.GetSearchParamsTypeAlbum:
+				return listPages(c, search.Albums(query), func(albums []api.Album, _ int) output.Result {
+					return output.Albums(albums)
+				})
+			case api.GetSearchParamsTypeArtist:
+				return listPages(c, search.Artists(query), func(artists []api.Artist, _ int) output.Result {
+					return output.Artists(artists)
+				})
+			}
+			// Numbered on from earlier pages, as play and download count them
+			return listPages(c, search.Tracks(query), output.TracksFrom)
+		},
+	}))
+}
+
+// trackFlag lets play/download take an API track ID instead of a result number
+var trackFlag = &cli.StringFlag{Name: "id", Usage: "track ID (instead of a number from the last search)"}
+
+// trackID resolves the track a command should act on: --id, or a number
+// from the last search
+func trackID(c *cli.Context) (string, error) {
+	if id := c.String("id"); id != "" {
+		return id, nil
+	}
+	if err := needArgs(c, 1, "<number from last search> | --id <track ID>"); err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(c.Args().First())
+	if err != nil {
+		return "", cli.Exit(fmt.Sprintf("%q is not a result number", c.Args().First()), exitUsage)
+	}
+	last, err := search.Last()
+	if err != nil {
+		return "", cli.Exit(fmt.Sprintf("could not load last search results: %v", err), exitError)
+	}
+	if n < 1 || n > len(last) {
+		return "", cli.Exit(fmt.Sprintf("track number %d not found in last search", n), exitNotFound)
+	}
+	return models.Value(last[n-1].Id), nil
+}
+
+func playCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "play",
+		Usage:     "play a track",
+		ArgsUsage: "<number from last search>",
+		Flags: []cli.Flag{
+			trackFlag,
+			qualityFlag,
+			playerFlag,
+		},
+		Action: func(c *cli.Context) error {
+			id, err := trackID(c)
+			if err != nil {
+				return err
+			}
+			o, err := playerOptions(c)
+			if err != nil {
+				return err
+			}
+			return fail(play.PlayTrackWith(o, id))
+		},
+	}
+}
+
+// downloadFlags override the downloads section of the config file
+var downloadFlags = []cli.Flag{
+	&cli.StringFlag{Name: "quality", DefaultText: download.DefaultQuality, Usage: "server quality: 27 hi-res FLAC, 7 24/96 FLAC, 6 CD FLAC, 5 MP3 320"},
+	&cli.StringFlag{Name: "dir", DefaultText: "current directory", Usage: "download root"},
+	&cli.StringFlag{Name: "template", Usage: "path template below the root, e.g. '{{.Artist}}/{{.Year}} - {{.Album}}/{{.Title}}'"},
+	&cli.StringFlag{Name: "on-exists", DefaultText: download.Skip, Usage: "when a file exists: skip, overwrite or suffix"},
+	&cli.BoolFlag{Name: "no-tags", Usage: "do not write tags or embed cover art"},
+	&cli.StringSliceFlag{Name: "transcode", Usage: "encode to these profiles (see `download profiles`), e.g. original,opus"},
+}
+
+// downloadOptions merges the config file with any download flags
+func downloadOptions(c *cli.Context) (download.Options, error) {
+	o := download.Configured()
+	if c.IsSet("quality") {
+		o.Quality = c.String("quality")
+	}
+	if c.IsSet("dir") {
+		o.Root = c.String("dir")
+	}
+	if c.IsSet("template") {
+		o.Template = c.String("template")
+	}
+	if c.IsSet("on-exists") {
+		o.OnExists = c.String("on-exists")
+	}
+	if c.IsSet("no-tags") {
+		o.NoTags = c.Bool("no-tags")
+	}
+	if c.IsSet("transcode") {
+		o.Transcode = nil
+		for _, v := range c.StringSlice("transcode") {
+			o.Transcode = append(o.Transcode, strings.Split(v, ",")...)
+		}
+	}
+	if err := o.Validate(); err != nil {
+		return o, cli.Exit(err.Error(), exitUsage)
+	}
+	return o, nil
+}
+
+var playerFlag = &cli.StringFlag{Name: "player", DefaultText: "from config, else mpv", Usage: "playback backend: " + strings.Join(play.Backends, ", ")}
+
+// qualityFlag picks the transcode profile a track plays through
+var qualityFlag = &cli.StringFlag{Name: "quality", DefaultText: "from config, else " + play.DefaultQuality, Usage: "low, medium, high, flac or another transcode profile"}
+
+// playerOptions applies --player and --quality over the player section
+// of the config file
+func playerOptions(c *cli.Context) (play.Options, error) {
+	o := play.Configured()
+	o.Terminal = true
+	if c.IsSet("player") {
+		o.Backend = c.String("player")
+	}
+	if c.IsSet("quality") {
+		o.Quality = c.String("quality")
+	}
+	if _, err := play.New(o); err != nil {
+		return o, cli.Exit(err.Error(), exitUsage)
+	}
+	return o, nil
+}
+
+func downloadCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "download",
+		Usage:     "download a track, or a whole album with `download album`",
+		ArgsUsage: "<number from last search>",
+		Flags: append([]cli.Flag{
+			trackFlag,
+			&cli.BoolFlag{Name: "resume", Usage: "finish downloads left unfinished under the download root"},
+		}, downloadFlags...),
+		Action: func(c *cli.Context) error {
+			opts, err := downloadOptions(c)
+			if err != nil {
+				return err
+			}
+			if c.Bool("resume") {
+				return downloadResume(c, opts)
+			}
+			// The path template and the tags need the whole track, not just its ID
+			var known []api.Track
+			if favs, err := favorites.LoadCache(); err == nil {
+				known = favs.Tracks()
+			}
+			tracks, err := pickTracks(c, c.Args().Slice(), "<number from last search> | --id <track ID>", known)
+			if err != nil {
+				return err
+			}
+			for _, t := range tracks {
+				results, err := download.Track(t, opts)
+				for _, res := range results {
+					switch {
+					case res.Skipped:
+						fmt.Fprintf(c.App.Writer, "Already downloaded: %s\n", res.Path)
+					case res.TagErr != nil:
+						fmt.Fprintf(c.App.Writer, "Track downloaded: %s, not tagged: %v\n", res.Path, res.TagErr)
+					default:
+						fmt.Fprintf(c.App.Writer, "Track downloaded: %s\n", res.Path)
+					}
+				}
+				if models.Value(t.Title) == "" && !opts.NoTags {
+					fmt.Fprintf(c.App.ErrWriter, "Track %s is in neither the last search nor the favorites, so it was not tagged.\n", models.Value(t.Id))
+				}
+				if err != nil {
+					return fail(err)
+				}
+			}
+			return nil
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:      "album",
+				Usage:     "download every track of an album, laid out by the path template",
+				ArgsUsage: "<album ID>",
+				Flags:     downloadFlags,
+				Action:    downloadAlbum,
+			},
+			{
+				Name:   "profiles",
+				Usage:  "list the transcode profiles, built in and from the config file",
+				Action: downloadProfiles,
+			},
+		},
+	}
+}
+
+func downloadAlbum(c *cli.Context) error {
+	if err := needArgs(c, 1, "<album ID>"); err != nil {
+		return err
+	}
+	opts, err := downloadOptions(c)
+	if err != nil {
+		return err
+	}
+	sum, err := download.Album(c.Args().First(), opts, printProgress(c.App.Writer))
+	if err != nil {
+		return fail(err)
+	}
+	return printSummary(c.App.Writer, sum)
+}
+
+func downloadProfiles(c *cli.Context) error {
+	w := c.App.Writer
+	fmt.Fprintf(w, "%-10s %s\n", download.Original, "the file as served, no transcoding")
+	profiles := transcode.Profiles()
+	for _, name := range transcode.Names() {
+		p := profiles[name]
+		rate := p.Bitrate
+		if p.Quality != "" {
+			rate = "VBR q" + p.Quality
+		}
+		if rate == "" {
+			rate = "lossless"
+		}
+		fmt.Fprintf(w, "%-10s %s %s (%s)\n", name, p.Codec, rate, p.Ext)
+	}
+	return nil
+}
+
+func downloadResume(c *cli.Context, opts download.Options) error {
+	sum, err := download.Resume(opts, printProgress(c.App.Writer))
+	if err != nil {
+		return fail(err)
+	}
+	if len(sum.Saved)+len(sum.Skipped)+len(sum.Failed) == 0 {
+		fmt.Fprintln(c.App.Writer, "Nothing to resume.")
+		return nil
+	}
+	return printSummary(c.App.Writer, sum)
+}
+
+// printProgress prints one line per track of a multi-track download
+func printProgress(w io.Writer) func(download.Progress) {
+	return func(p download.Progress) {
+		switch {
+		case !p.Done:
+			title := models.Value(p.Track.Title)
+			if p.Profile != "" {
+				title += " [" + p.Profile + "]"
+			}
+			fmt.Fprintf(w, "[%d/%d] %s ... ", p.Index, p.Total, title)
+		case p.Err != nil:
+			fmt.Fprintf(w, "failed: %v\n", p.Err)
+		case p.Result.Skipped:
+			fmt.Fprintf(w, "exists, skipped\n")
+		case p.Result.TagErr != nil:
+			fmt.Fprintf(w, "%s, not tagged: %v\n", models.Bytes(p.Result.Bytes), p.Result.TagErr)
+		default:
+			fmt.Fprintf(w, "%s\n", models.Bytes(p.Result.Bytes))
+		}
+	}
+}
+
+func printSummary(w io.Writer, sum *download.Summary) error {
+	total := len(sum.Saved) + len(sum.Skipped) + len(sum.Failed)
+	fmt.Fprintf(w, "Downloaded %d of %d tracks (%s)", len(sum.Saved), total, models.Bytes(sum.Bytes))
+	if len(sum.Skipped) > 0 {
+		fmt.Fprintf(w, ", %d already present", len(sum.Skipped))
+	}
+	fmt.Fprintln(w)
+	if len(sum.Failed) > 0 {
+		return cli.Exit(fmt.Sprintf("%d tracks failed; run `dab download --resume` to retry", len(sum.Failed)), exitError)
+	}
+	return nil
+}
+
+func albumCommand() *cli.Command {
+	return withFormat(&cli.Command{
+		Name:      "album",
+		Usage:     "show an album and its tracklist",
+		ArgsUsage: "<album ID>",
+		Action: func(c *cli.Context) error {
+			if err := needArgs(c, 1, "<album ID>"); err != nil {
+				return err
+			}
+			a, err := album.Get(c.Args().First())
+			if err != nil {
+				return fail(err)
+			}
+			return render(c, output.Album(*a))
+		},
+	})
+}
+
+func artistCommand() *cli.Command {
+	return withFormat(&cli.Command{
+		Name:      "artist",
+		Usage:     "show an artist's biography and discography",
+		ArgsUsage: "<artist ID>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "sort", Value: artist.SortNewest, Usage: "newest, oldest or title"},
+			&cli.BoolFlag{Name: "group", Aliases: []string{"g"}, Usage: "group albums by release type"},
+			&cli.BoolFlag{Name: "similar", Usage: "list similar artists instead of albums"},
+		},
+		Action: func(c *cli.Context) error {
+			if err := needArgs(c, 1, "<artist ID>"); err != nil {
+				return err
+			}
+			ar, albums, err := artist.Discography(c.Args().First())
+			if err != nil {
+				return fail(err)
+			}
+
+			if c.Bool("similar") {
+				similar, err := artist.Similar(*ar)
+				if err != nil {
+					return fail(err)
+				}
+				return render(c, output.Artists(similar))
+			}
+
+			albums, err = artist.SortAlbums(albums, c.String("sort"))
+			if err != nil {
+				return cli.Exit(err.Error(), exitUsage)
+			}
+			if c.Bool("group") {
+				// Keep the sort order within each group
+				var grouped []api.Album
+				for _, g := range artist.GroupAlbums(albums) {
+					grouped = append(grouped, g.Albums...)
+				}
+				albums = grouped
+			}
+			return render(c, output.Discography(*ar, albums, c.Bool("group")))
+		},
+	})
+}
+
+func lyricsCommand() *cli.Command {
+	return withFormat(&cli.Command{
+		Name:      "lyrics",
+		Usage:     "print the lyrics of a song",
+		ArgsUsage: "<artist> <title>",
+		Action: func(c *cli.Context) error {
+			if err := needArgs(c, 2, "<artist> <title>"); err != nil {
+				return err
+			}
+			l, err := lyrics.Get(c.Args().Get(0), strings.Join(c.Args().Slice()[1:], " "))
+			if err != nil {
+				return fail(err)
+			}
+			return render(c, output.Lyrics(*l))
+		},
+	})
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+	"github.com/adityadeshmukh1/dab-cli/internal/play"
+)
+
+// isPlaying reports whether t is the track that is playing
+func (p *nowPlaying) isPlaying(t api.Track) bool {
+	return p.playing && t.Id != nil && models.Value(t.Id) == models.Value(p.track.Id)
+}
+
+// trackLine renders a row of a track list, marking the cursor and the
+// track that is playing
+func (p *nowPlaying) trackLine(t api.Track, line string, selected bool) string {
+	playing := p.isPlaying(t)
+	switch {
+	case selected && playing:
+		return selectedItemStyle.Render("♪ " + line)
+	case selected:
+		return selectedItemStyle.Render("> " + line)
+	case playing:
+		return playingItemStyle.Render("♪ " + line)
+	}
+	return itemStyle.Render(line)
+}
+
+// view shows the playing track, following the player's position events
+func (p *nowPlaying) view(width, height int) string {
+	if !p.playing {
+		return helpStyle.UnsetMargins().Render("Nothing playing.")
+	}
+
+	t := p.track
+	state := "▶"
+	if p.paused {
+		state = "⏸"
+	}
+	quality := ""
+	if t.AudioQuality != nil {
+		quality = " · " + models.Quality(t.AudioQuality)
+	}
+	s := fmt.Sprintf("%s %s\n", state, panelTitleStyle.Render(models.Value(t.Title)))
+	s += fmt.Sprintf("  %s · %s%s\n", models.Value(t.Artist), models.Value(t.AlbumTitle), quality)
+
+	remaining := "--:--"
+	if p.dur > 0 {
+		remaining = "-" + models.Duration(max(int(p.dur)-int(p.pos), 0))
+	}
+	s += fmt.Sprintf("  %s %s / %s  %s", progressBar(int64(p.pos), int64(p.dur)),
+		models.Duration(int(p.pos)), models.Duration(int(p.dur)), remaining)
+	if _, ok := p.player.(play.Mixer); ok {
+		if p.muted {
+			s += "  muted"
+		} else {
+			s += fmt.Sprintf("  vol %.0f%%", p.volume)
+		}
+	}
+	return s
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"context"
+	"sync/atomic"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/pager"
+)
+
+// prefetchRows is how close to the end of what is loaded the cursor gets
+// before the next page is fetched
+const prefetchRows = 5
+
+// pagedLists hands out list IDs, so pages find the list that asked
+var pagedLists atomic.Int64
+
+// pageMsg delivers a page to the list, and the pager of it, that asked
+type pageMsg[T any] struct {
+	list int64
+	gen  int
+	page pager.Page[T]
+	err  error
+}
+
+// pagedList is a cursor over a pager that fetches the next page as the
+// cursor nears the bottom. Starting over with another pager cancels the
+// fetch still running for the last one and drops pages meant for it, so
+// a slow reply never lands in a newer list.
+type pagedList[T any] struct {
+	id      int64
+	gen     int // counts pagers, to drop pages of earlier ones
+	pager   *pager.Pager[T]
+	items   []T // shown; those of the last pager until the new one has a page
+	cursor  int
+	loading bool
+	err     string
+	cancel  context.CancelFunc // cancels the fetch running, nil when none
+}
+
+func newPagedList[T any]() *pagedList[T] {
+	return &pagedList[T]{id: pagedLists.Add(1)}
+}
+
+// reset starts over with p, loading its first page unless it has one.
+// The items shown so far stay until then.
+func (l *pagedList[T]) reset(p *pager.Pager[T]) tea.Cmd {
+	l.abandon()
+	l.pager, l.err = p, ""
+	if p.Pages() > 0 {
+		l.items, l.cursor = p.Items(), 0
+		return nil
+	}
+	return l.load()
+}
+
+// clear empties the list
+func (l *pagedList[T]) clear() {
+	l.abandon()
+	l.pager, l.items, l.cursor, l.err = nil, nil, 0, ""
+}
+
+// abandon cancels the fetch running and drops whatever it brings back
+func (l *pagedList[T]) abandon() {
+	l.done()
+	l.gen++
+}
+
+// done releases the fetch running
+func (l *pagedList[T]) done() {
+	if l.cancel != nil {
+		l.cancel()
+		l.cancel = nil
+	}
+	l.loading = false
+}
+
+func (l *pagedList[T]) load() tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel, l.loading, l.err = cancel, true, ""
+	p, id, gen, number := l.pager, l.id, l.gen, l.pager.NextPage()
+	return func() tea.Msg {
+		pg, err := p.Fetch(ctx, number)
+		return pageMsg[T]{list: id, gen: gen, page: pg, err: err}
+	}
+}
+
+// ready reports whether the items shown are the current pager's
+func (l *pagedList[T]) ready() bool {
+	return l.pager != nil && l.pager.Pages() > 0
+}
+
+// pending reports whether the current pager is still on its first page
+func (l *pagedList[T]) pending() bool {
+	return l.pager != nil && l.pager.Pages() == 0 && l.err == ""
+}
+
+func (l *pagedList[T]) failed() string { return l.err }
+func (l *pagedList[T]) len() int       { return len(l.items) }
+
+// selected is the item under the cursor; the list must not be empty
+func (l *pagedList[T]) selected() T {
+	return l.items[l.cursor]
+}
+
+func (l *pagedList[T]) update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case pageMsg[T]:
+		if msg.list != l.id || msg.gen != l.gen {
+			return nil // meant for another list, or an earlier pager
+		}
+		l.done()
+		if msg.err != nil {
+			l.err = msg.err.Error()
+			return nil
+		}
+		first := !l.ready()
+		l.pager.Add(msg.page)
+		l.items = l.pager.Items()
+		if first {
+			l.cursor = 0
+		}
+		return l.prefetch()
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if l.cursor > 0 {
+				l.cursor--
+			}
+		case "down", "j":
+			if l.cursor < len(l.items)-1 {
+				l.cursor++
+			}
+		}
+		return l.prefetch()
+	}
+	return nil
+}
+
+// prefetch loads the next page once the cursor nears the end of the
+// loaded items. A failed page is tried again the next time it moves.
+func (l *pagedList[T]) prefetch() tea.Cmd {
+	if !l.ready() || l.loading || !l.pager.More() || l.cursor < len(l.items)-prefetchRows {
+		return nil
+	}
+	return l.load()
+}
+
+// rows renders the items around the cursor that fit in height
+func (l *pagedList[T]) rows(height int, row func(i int, item T, selected bool) string) []string {
+	var out []string
+	start, end := window(l.cursor, len(l.items), height)
+	for i := start; i < end; i++ {
+		out = append(out, row(i, l.items[i], l.cursor == i))
+	}
+	return out
+}
+
+// status is "loaded X of Y", with how the next page is going
+func (l *pagedList[T]) status() string {
+	if !l.ready() {
+		return ""
+	}
+	s := l.pager.Status()
+	switch {
+	case l.err != "":
+		s += " · [ERROR] " + l.err
+	case l.loading:
+		s += " · loading more..."
+	}
+	return s
+}
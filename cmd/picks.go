@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+)
+
+// picks are the tracks marked with v in a list, for l to add them to a
+// library together
+type picks map[string]bool
+
+func (p *picks) toggle(t api.Track) {
+	if *p == nil {
+		*p = picks{}
+	}
+	id := models.Value(t.Id)
+	if (*p)[id] {
+		delete(*p, id)
+	} else {
+		(*p)[id] = true
+	}
+}
+
+// of is the marked tracks of list in order, or the one at cursor when
+// none are marked
+func (p picks) of(list []api.Track, cursor int) []api.Track {
+	var out []api.Track
+	for _, t := range list {
+		if p[models.Value(t.Id)] {
+			out = append(out, t)
+		}
+	}
+	if len(out) == 0 && cursor < len(list) {
+		out = append(out, list[cursor])
+	}
+	return out
+}
+
+// mark flags a marked track in its row
+func (p picks) mark(t api.Track) string {
+	if p[models.Value(t.Id)] {
+		return " ✓"
+	}
+	return ""
+}
+
+// addToLibrary asks for a library to add the marked tracks to, or the
+// one at cursor, and unmarks them
+func (p *picks) addToLibrary(list []api.Track, cursor int) tea.Cmd {
+	tracks := p.of(list, cursor)
+	if len(tracks) == 0 {
+		return nil
+	}
+	*p = nil
+	what := models.Value(tracks[0].Title)
+	if len(tracks) > 1 {
+		what = countLabel(len(tracks), "track")
+	}
+	return intent(addToLibraryMsg{tracks: tracks, what: what})
+}
@@ -0,0 +1,351 @@
+package cmd
+
+import (
+	"sync"
+	"sync/atomic"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+	"github.com/adityadeshmukh1/dab-cli/internal/play"
+	"github.com/adityadeshmukh1/dab-cli/internal/queue"
+)
+
+const (
+	seekStep   = 10 // seconds
+	volumeStep = 5  // percent
+)
+
+// Playback runs in the background: commands load tracks on the player
+// and its events come back as these messages, so the TUI stays usable
+// while music plays.
+type (
+	// playbackLoadedMsg reports that a track was handed to the player
+	playbackLoadedMsg struct {
+		track api.Track
+	}
+	// playbackStartedMsg reports that the loaded track started playing
+	playbackStartedMsg struct {
+		duration float64
+		mix      mix
+	}
+	// playbackProgressMsg reports the position, and pauses and resumes
+	playbackProgressMsg struct {
+		position, duration float64
+		paused             bool
+		mix                mix
+	}
+	// playbackEndedMsg reports that the track played out, or was
+	// stopped or replaced
+	playbackEndedMsg struct {
+		stopped bool
+	}
+	// playbackErrorMsg reports a track that failed to load, or the
+	// player failing to play it
+	playbackErrorMsg struct {
+		err    error
+		player bool
+	}
+	// playbackControlMsg reports how the player took a playback key
+	playbackControlMsg struct {
+		key    string
+		paused bool // for the pause key: whether it is paused now
+		err    error
+	}
+)
+
+// mix is the player's volume as of an event; ok is false when the
+// player has no volume control or did not answer
+type mix struct {
+	volume float64
+	muted  bool
+	ok     bool
+}
+
+// readMix asks the player for its volume. It may wait on the player, so
+// it runs in the command that delivers the event, never in Update.
+func readMix(p play.Player) mix {
+	mixer, ok := p.(play.Mixer)
+	if !ok {
+		return mix{}
+	}
+	vol, muted, err := mixer.Volume()
+	return mix{volume: vol, muted: muted, ok: err == nil}
+}
+
+// waitForPlayer delivers the player's next event; like waitForDownload,
+// Update re-issues it after each one
+func waitForPlayer(p play.Player) tea.Cmd {
+	if p == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		e := <-p.Events()
+		switch e.Kind {
+		case play.Started:
+			return playbackStartedMsg{duration: e.Duration, mix: readMix(p)}
+		case play.Ended, play.Stopped:
+			return playbackEndedMsg{stopped: e.Kind == play.Stopped}
+		case play.Failed:
+			return playbackErrorMsg{err: e.Err, player: true}
+		}
+		return playbackProgressMsg{position: e.Position, duration: e.Duration, paused: e.Kind == play.Paused, mix: readMix(p)}
+	}
+}
+
+// loader hands tracks to the player one at a time. A track picked while
+// another was still loading supersedes it; the older one is skipped.
+type loader struct {
+	mu  sync.Mutex
+	gen atomic.Int64
+}
+
+// load returns a command that loads and plays t, unless another load
+// was asked for in the meantime
+func (l *loader) load(p play.Player, t api.Track, quality string) tea.Cmd {
+	gen := l.gen.Add(1)
+	return func() tea.Msg {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.gen.Load() != gen {
+			return nil
+		}
+		src, err := play.TrackSource(models.Value(t.Id), models.Value(t.Duration), quality)
+		if err == nil {
+			err = p.Load(src)
+		}
+		if err == nil {
+			err = p.Play()
+		}
+		if err != nil {
+			return playbackErrorMsg{err: err}
+		}
+		return playbackLoadedMsg{track: t}
+	}
+}
+
+// stop returns a command that stops the player, superseding any load
+func (l *loader) stop(p play.Player) tea.Cmd {
+	l.gen.Add(1)
+	return func() tea.Msg {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		p.Stop()
+		return nil
+	}
+}
+
+// control returns a command that runs a playback key's action on the
+// player, after any load in progress
+func (l *loader) control(key string, do func() (paused bool, err error)) tea.Cmd {
+	return func() tea.Msg {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		paused, err := do()
+		return playbackControlMsg{key: key, paused: paused, err: err}
+	}
+}
+
+// nowPlaying is the now-playing panel. It owns the player and decides
+// what plays next: the rest of an album or list, or the queue.
+type nowPlaying struct {
+	player    play.Player // nil if the configured backend is unknown
+	playerErr string
+	quality   string // transcode profile tracks are streamed through
+	loader    *loader
+	queue     *queue.Queue
+
+	list      []api.Track // what plays after the current track, e.g. an album
+	index     int
+	fromQueue bool // the queue decides what plays next
+
+	playing       bool
+	track         api.Track
+	pos, dur      float64 // seconds
+	paused, muted bool
+	volume        float64
+	status        string
+}
+
+func newNowPlaying(q *queue.Queue, quality string) *nowPlaying {
+	return &nowPlaying{loader: &loader{}, queue: q, quality: quality}
+}
+
+func (p *nowPlaying) title() string { return "Now playing" }
+func (p *nowPlaying) typing() bool  { return false }
+
+func (p *nowPlaying) help() string {
+	return "Space pause · ←/→ seek · +/- volume · m mute · S stop"
+}
+
+// playTracks plays tracks back to back from index
+func (p *nowPlaying) playTracks(tracks []api.Track, index int) tea.Cmd {
+	if index >= len(tracks) {
+		return nil
+	}
+	p.list, p.index, p.fromQueue = tracks, index, false
+	return p.start(tracks[index])
+}
+
+// playQueue plays the queue from index on, advancing as the queue
+// would: with its repeat mode, and moving its current track
+func (p *nowPlaying) playQueue(index int) tea.Cmd {
+	if p.queue == nil {
+		return nil
+	}
+	t, err := p.queue.Jump(index)
+	if err != nil {
+		p.status = "[ERROR] " + err.Error()
+		return nil
+	}
+	p.list, p.fromQueue = nil, true
+	return p.start(t)
+}
+
+func (p *nowPlaying) start(t api.Track) tea.Cmd {
+	if p.player == nil {
+		p.status = "[ERROR] " + p.playerErr
+		return nil
+	}
+	p.status = "Starting " + models.Value(t.Title) + "..."
+	return p.loader.load(p.player, t, p.quality)
+}
+
+// next starts whatever follows a track that played out
+func (p *nowPlaying) next() tea.Cmd {
+	switch {
+	case p.fromQueue && p.queue != nil:
+		t, ok, err := p.queue.Ended()
+		if err != nil {
+			p.status = "[ERROR] " + err.Error()
+		}
+		if ok {
+			return p.start(t)
+		}
+	case p.index+1 < len(p.list):
+		p.index++
+		return p.start(p.list[p.index])
+	}
+	p.list, p.fromQueue = nil, false
+	return nil
+}
+
+// quit stops the player, after any load in progress, and then quits
+func (p *nowPlaying) quit() tea.Cmd {
+	if p.player == nil {
+		return tea.Quit
+	}
+	return tea.Sequence(p.loader.stop(p.player), tea.Quit)
+}
+
+func (p *nowPlaying) reset() {
+	p.playing = false
+	p.pos, p.dur = 0, 0
+	p.paused, p.muted = false, false
+}
+
+func (p *nowPlaying) update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case playMsg:
+		return p.playTracks(msg.tracks, msg.index)
+	case playQueueMsg:
+		return p.playQueue(int(msg))
+
+	case playbackLoadedMsg:
+		p.track, p.status = msg.track, ""
+		return nil
+
+	// Player events come in order, so the end of a track being replaced
+	// always arrives before the start of the new one
+	case playbackStartedMsg:
+		p.playing, p.paused = true, false
+		p.pos, p.dur = 0, msg.duration
+		p.setMix(msg.mix)
+	case playbackProgressMsg:
+		p.pos, p.dur, p.paused = msg.position, msg.duration, msg.paused
+		p.setMix(msg.mix)
+	case playbackEndedMsg:
+		p.reset()
+		if !msg.stopped {
+			cmd = p.next()
+		}
+	case playbackErrorMsg:
+		p.reset()
+		p.list, p.fromQueue = nil, false
+		p.status = "[ERROR] " + msg.err.Error()
+		if !msg.player {
+			return nil
+		}
+	case playbackControlMsg:
+		p.status = ""
+		switch {
+		case msg.err != nil:
+			p.status = "[ERROR] " + msg.err.Error()
+		case msg.key == " ":
+			p.paused = msg.paused
+		case msg.key == "m":
+			p.muted = !p.muted
+		}
+		return nil
+	default:
+		return nil
+	}
+	return tea.Batch(cmd, waitForPlayer(p.player))
+}
+
+func (p *nowPlaying) setMix(m mix) {
+	if m.ok {
+		p.volume, p.muted = m.volume, m.muted
+	}
+}
+
+// key handles the playback keys, which work from any panel; handled is
+// false for any other key, or when nothing is playing. The player is
+// only asked in the returned command, since it may take a while to answer.
+func (p *nowPlaying) key(msg tea.KeyMsg) (_ tea.Cmd, handled bool) {
+	if !p.playing {
+		return nil, false
+	}
+	player := p.player
+	mixer, _ := player.(play.Mixer)
+	key := msg.String()
+	var do func() (bool, error)
+	switch key {
+	case " ":
+		paused := p.paused
+		do = func() (bool, error) {
+			if paused {
+				return false, player.Play()
+			}
+			return true, player.Pause()
+		}
+	case "left", "right":
+		step := float64(seekStep)
+		if key == "left" {
+			step = -step
+		}
+		do = func() (bool, error) { return false, player.Seek(step, true) }
+	case "+", "=", "-", "m":
+		if mixer == nil {
+			p.status = "This player has no volume control."
+			return nil, true
+		}
+		switch key {
+		case "-":
+			do = func() (bool, error) { return false, mixer.AddVolume(-volumeStep) }
+		case "m":
+			do = func() (bool, error) { return false, mixer.ToggleMute() }
+		default:
+			do = func() (bool, error) { return false, mixer.AddVolume(volumeStep) }
+		}
+	case "S":
+		p.list, p.fromQueue, p.status = nil, false, ""
+		p.reset()
+		return p.loader.stop(player), true
+	default:
+		return nil, false
+	}
+	return p.loader.control(key, do), true
+}
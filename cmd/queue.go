@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/album"
+	"github.com/adityadeshmukh1/dab-cli/internal/config"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+	"github.com/adityadeshmukh1/dab-cli/internal/output"
+	"github.com/adityadeshmukh1/dab-cli/internal/play"
+	"github.com/adityadeshmukh1/dab-cli/internal/queue"
+)
+
+func queueCommand() *cli.Command {
+	list := func(c *cli.Context) error {
+		q, err := loadQueue(c)
+		if err != nil {
+			return fail(err)
+		}
+		_, current, _ := q.Current()
+		return render(c, output.Queue(q.Tracks(), current, q.Repeat()))
+	}
+
+	return withFormat(&cli.Command{
+		Name:   "queue",
+		Usage:  "show and edit the local play queue",
+		Action: list,
+		Subcommands: []*cli.Command{
+			withFormat(&cli.Command{Name: "list", Usage: "show the queue", Action: list}),
+			{
+				Name:  "play",
+				Usage: "play the queue from the current track",
+				Flags: []cli.Flag{
+					qualityFlag,
+					playerFlag,
+				},
+				Action: func(c *cli.Context) error {
+					o, err := playerOptions(c)
+					if err != nil {
+						return err
+					}
+					return editQueue(c, func(q *queue.Queue) error {
+						return q.Play(func(t api.Track) error {
+							fmt.Fprintf(c.App.Writer, "Playing %s - %s\n", models.Value(t.Title), models.Value(t.Artist))
+							return play.PlayTrackWith(o, models.Value(t.Id))
+						})
+					})
+				},
+			},
+			{
+				Name:      "album",
+				Usage:     "queue every track of an album",
+				ArgsUsage: "<album ID>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "next", Usage: "play the album after the current track instead of at the end"},
+				},
+				Action: func(c *cli.Context) error {
+					if err := needArgs(c, 1, "<album ID>"); err != nil {
+						return err
+					}
+					a, err := album.Get(c.Args().First())
+					if err != nil {
+						return fail(err)
+					}
+					tracks := models.Value(a.Tracks)
+					err = editQueue(c, func(q *queue.Queue) error {
+						if c.Bool("next") {
+							return q.InsertNext(tracks...)
+						}
+						return q.Enqueue(tracks...)
+					})
+					if err == nil {
+						fmt.Fprintf(c.App.Writer, "Queued %d tracks from %s.\n", len(tracks), models.Value(a.Title))
+					}
+					return err
+				},
+			},
+			{
+				Name:      "rm",
+				Usage:     "remove a track from the queue",
+				ArgsUsage: "<position>",
+				Action: func(c *cli.Context) error {
+					i, err := queuePosition(c, 0, "<position>")
+					if err != nil {
+						return err
+					}
+					return editQueue(c, func(q *queue.Queue) error { return q.Remove(i) })
+				},
+			},
+			{
+				Name:      "mv",
+				Usage:     "move a track to another position",
+				ArgsUsage: "<from> <to>",
+				Action: func(c *cli.Context) error {
+					from, err := queuePosition(c, 0, "<from> <to>")
+					if err != nil {
+						return err
+					}
+					to, err := queuePosition(c, 1, "<from> <to>")
+					if err != nil {
+						return err
+					}
+					return editQueue(c, func(q *queue.Queue) error { return q.Move(from, to) })
+				},
+			},
+			{
+				Name:      "jump",
+				Usage:     "make a track the current one",
+				ArgsUsage: "<position>",
+				Action: func(c *cli.Context) error {
+					i, err := queuePosition(c, 0, "<position>")
+					if err != nil {
+						return err
+					}
+					return editQueue(c, func(q *queue.Queue) error {
+						_, err := q.Jump(i)
+						return err
+					})
+				},
+			},
+			{
+				Name:   "shuffle",
+				Usage:  "shuffle the tracks after the current one",
+				Action: func(c *cli.Context) error { return editQueue(c, (*queue.Queue).Shuffle) },
+			},
+			{
+				Name:      "repeat",
+				Usage:     "set the repeat mode",
+				ArgsUsage: "off|one|all",
+				Action: func(c *cli.Context) error {
+					if err := needArgs(c, 1, "off|one|all"); err != nil {
+						return err
+					}
+					q, err := queue.Load()
+					if err != nil {
+						return fail(err)
+					}
+					if err := q.SetRepeat(c.Args().First()); err != nil {
+						return cli.Exit(err.Error(), exitUsage)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "clear",
+				Usage: "empty the queue, here and on the server",
+				Action: func(c *cli.Context) error {
+					q, err := queue.Load()
+					if err != nil {
+						return fail(err)
+					}
+					if !queueSync() {
+						return fail(q.Clear())
+					}
+					if err := q.ClearAll(); err != nil {
+						return cli.Exit(fmt.Sprintf("Cleared locally; the server will follow on the next sync: %v", err), exitUnsynced)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "pull",
+				Usage: "replace the local queue with the server's",
+				Action: func(c *cli.Context) error {
+					q, err := queue.Load()
+					if err != nil {
+						return fail(err)
+					}
+					if err := q.Pull(); err != nil {
+						return fail(err)
+					}
+					fmt.Fprintf(c.App.Writer, "Pulled %d tracks.\n", q.Len())
+					return nil
+				},
+			},
+			{
+				Name:  "push",
+				Usage: "replace the server's queue with the local one",
+				Action: func(c *cli.Context) error {
+					q, err := queue.Load()
+					if err != nil {
+						return fail(err)
+					}
+					if err := q.Push(); err != nil {
+						return fail(err)
+					}
+					fmt.Fprintf(c.App.Writer, "Pushed %d tracks.\n", q.Len())
+					return nil
+				},
+			},
+		},
+	})
+}
+
+// queueSync reports whether the queue follows the server's
+func queueSync() bool {
+	return !config.Active().Queue.NoSync
+}
+
+// loadQueue loads the local queue and syncs it with the server. A
+// failed sync is reported and the local queue used as it is.
+func loadQueue(c *cli.Context) (*queue.Queue, error) {
+	q, err := queue.Load()
+	if err != nil || !queueSync() {
+		return q, err
+	}
+	res, err := q.Sync()
+	switch {
+	case err != nil:
+		fmt.Fprintf(c.App.ErrWriter, "Queue not synced, using the local copy: %v\n", err)
+	case res.Conflict && res.Action == queue.Pushed:
+		fmt.Fprintln(c.App.ErrWriter, "The server's queue changed too; kept the newer local one.")
+	case res.Conflict:
+		fmt.Fprintln(c.App.ErrWriter, "The local queue changed too; took the newer one from the server.")
+	}
+	return q, nil
+}
+
+// editQueue loads and syncs the queue, applies a change to it and
+// pushes the change. A change the server did not take is kept locally
+// and exits with exitUnsynced.
+func editQueue(c *cli.Context, edit func(*queue.Queue) error) error {
+	q, err := loadQueue(c)
+	if err != nil {
+		return fail(err)
+	}
+	if err := edit(q); err != nil {
+		return fail(err)
+	}
+	if queueSync() && q.Pending() {
+		if err := q.Push(); err != nil {
+			return cli.Exit(fmt.Sprintf("Saved locally; the server will follow on the next sync: %v", err), exitUnsynced)
+		}
+	}
+	return nil
+}
+
+// queuePosition reads the 1-based queue position in argument n
+func queuePosition(c *cli.Context, n int, usage string) (int, error) {
+	if err := needArgs(c, n+1, usage); err != nil {
+		return 0, err
+	}
+	i, err := strconv.Atoi(c.Args().Get(n))
+	if err != nil || i < 1 {
+		return 0, cli.Exit(fmt.Sprintf("invalid queue position %q", c.Args().Get(n)), exitUsage)
+	}
+	return i - 1, nil
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/favorites"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+	"github.com/adityadeshmukh1/dab-cli/internal/queue"
+)
+
+// queueSyncMsg reports a background sync of the queue with the server
+type queueSyncMsg queue.SyncEvent
+
+// waitForQueueSync delivers the syncer's next result; like
+// waitForDownload, Update re-issues it after each one
+func waitForQueueSync(s *queue.Syncer) tea.Cmd {
+	if s == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return queueSyncMsg(<-s.Events())
+	}
+}
+
+// queuePanel shows and edits the local play queue
+type queuePanel struct {
+	queue  *queue.Queue  // nil if it failed to load
+	sync   *queue.Syncer // nil when the queue stays local
+	err    string
+	cursor int
+	status string
+	np     *nowPlaying
+	favs   *favorites.Cache
+	picks  picks
+}
+
+func (p *queuePanel) title() string { return "Queue" }
+func (p *queuePanel) typing() bool  { return false }
+
+func (p *queuePanel) help() string {
+	return "Enter play from here · x remove · f favorite · v mark · l add to library · J/K move · s shuffle · r repeat · c clear"
+}
+
+// enqueue adds tracks to the queue, at the end or after the current
+// track, and reports it in the status line
+func (p *queuePanel) enqueue(msg enqueueMsg) tea.Cmd {
+	if p.queue == nil {
+		return status("", fmt.Errorf("queue unavailable: %s", p.err))
+	}
+	if msg.next {
+		return status(fmt.Sprintf("%s plays next.", msg.what), p.queue.InsertNext(msg.tracks...))
+	}
+	return status(fmt.Sprintf("Queued %s.", msg.what), p.queue.Enqueue(msg.tracks...))
+}
+
+func (p *queuePanel) update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case enqueueMsg:
+		return p.enqueue(msg)
+
+	case queueSyncMsg:
+		switch {
+		case msg.Err != nil:
+			p.status = "Queue not synced: " + msg.Err.Error()
+		case msg.Result.Action == queue.Pulled:
+			p.status = "Queue updated from the server."
+		}
+		return waitForQueueSync(p.sync)
+
+	case focusMsg:
+		if panelID(msg) == queuePanelID && p.queue != nil {
+			if _, current, ok := p.queue.Current(); ok {
+				p.cursor = current
+			}
+		}
+
+	case tea.KeyMsg:
+		if p.queue != nil {
+			return p.updateKey(msg)
+		}
+	}
+	return nil
+}
+
+func (p *queuePanel) updateKey(msg tea.KeyMsg) tea.Cmd {
+	n := p.queue.Len()
+	var err error
+	switch msg.String() {
+	case "up", "k":
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case "down", "j":
+		if p.cursor < n-1 {
+			p.cursor++
+		}
+	case "enter":
+		if n > 0 {
+			p.status = ""
+			return intent(playQueueMsg(p.cursor))
+		}
+	case "x", "delete":
+		if n > 0 {
+			err = p.queue.Remove(p.cursor)
+			if p.cursor >= n-1 && p.cursor > 0 {
+				p.cursor--
+			}
+		}
+	case "K", "shift+up":
+		if p.cursor > 0 {
+			if err = p.queue.Move(p.cursor, p.cursor-1); err == nil {
+				p.cursor--
+			}
+		}
+	case "J", "shift+down":
+		if p.cursor < n-1 {
+			if err = p.queue.Move(p.cursor, p.cursor+1); err == nil {
+				p.cursor++
+			}
+		}
+	case "f":
+		if n > 0 {
+			return intent(toggleFavoriteMsg(p.queue.Tracks()[p.cursor]))
+		}
+	case "v":
+		if n > 0 {
+			p.picks.toggle(p.queue.Tracks()[p.cursor])
+		}
+	case "l":
+		return p.picks.addToLibrary(p.queue.Tracks(), p.cursor)
+	case "s":
+		err = p.queue.Shuffle()
+	case "r":
+		var mode string
+		if mode, err = p.queue.CycleRepeat(); err == nil {
+			p.status = "Repeat " + mode
+			return nil
+		}
+	case "c":
+		err = p.queue.Clear()
+		p.cursor = 0
+	}
+	p.status = ""
+	if err != nil {
+		p.status = "[ERROR] " + err.Error()
+	}
+	return nil
+}
+
+func (p *queuePanel) view(width, height int) string {
+	if p.queue == nil {
+		return "[ERROR] " + p.err
+	}
+
+	tracks := p.queue.Tracks()
+	_, current, _ := p.queue.Current()
+	footer := []string{"", itemStyle.Render("Repeat: " + p.queue.Repeat())}
+	if p.status != "" {
+		footer = append(footer, p.status)
+	}
+	var out []string
+	if len(tracks) == 0 {
+		out = append(out, itemStyle.Render("The queue is empty. Press a on an album or pick Add to queue on a track."))
+	}
+	start, end := window(p.cursor, len(tracks), height-len(footer))
+	for i := start; i < end; i++ {
+		t := tracks[i]
+		mark := "  "
+		if i == current {
+			mark = "♪ "
+		}
+		line := fmt.Sprintf("%s%2d. %-40s %6s%s", mark, i+1, models.Value(t.Title)+" - "+models.Value(t.Artist),
+			models.Duration(models.Value(t.Duration)), heart(p.favs, t)+p.picks.mark(t))
+		switch {
+		case p.cursor == i:
+			out = append(out, selectedItemStyle.Render("> "+line))
+		case p.np.isPlaying(t) && (i == current || !p.np.fromQueue):
+			out = append(out, playingItemStyle.Render("  "+line))
+		default:
+			out = append(out, itemStyle.Render(line))
+		}
+	}
+	return lines(append(out, footer...))
+}
@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/favorites"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+	"github.com/adityadeshmukh1/dab-cli/internal/pager"
+	"github.com/adityadeshmukh1/dab-cli/internal/search"
+)
+
+// trackActions is the submenu shown for a track search result
+var trackActions = []string{"Play", "Download", "Add to queue", "Play next", "Add to library…"}
+
+// resultList is what the results panel needs of a pagedList of any kind
+type resultList interface {
+	ready() bool
+	pending() bool
+	failed() string
+	len() int
+	clear()
+	status() string
+	update(msg tea.Msg) tea.Cmd
+}
+
+// resultsPanel lists the results of the last search, a page at a time.
+// Each kind of result has its own list; a new search supersedes the one
+// running (see pagedList), and the results shown stay until its first
+// page arrives.
+type resultsPanel struct {
+	np   *nowPlaying
+	favs *favorites.Cache
+
+	query   string                  // latest search
+	kind    api.GetSearchParamsType // of the latest search
+	shown   api.GetSearchParamsType // of the results shown
+	tracks  *pagedList[api.Track]
+	albums  *pagedList[api.Album]
+	artists *pagedList[api.Artist]
+	picks   picks // of the tracks
+	spinner spinner.Model
+
+	actionOpen   bool // whether the submenu (trackActions) is open
+	actionCursor int  // index into trackActions
+}
+
+func newResultsPanel(np *nowPlaying, favs *favorites.Cache) *resultsPanel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	return &resultsPanel{
+		np:      np,
+		favs:    favs,
+		kind:    api.GetSearchParamsTypeTrack,
+		shown:   api.GetSearchParamsTypeTrack,
+		tracks:  newPagedList[api.Track](),
+		albums:  newPagedList[api.Album](),
+		artists: newPagedList[api.Artist](),
+		spinner: s,
+	}
+}
+
+func (p *resultsPanel) title() string { return "Results" }
+func (p *resultsPanel) typing() bool  { return false }
+
+func (p *resultsPanel) help() string {
+	if p.actionOpen {
+		return "Enter pick · Esc close"
+	}
+	if p.shown == api.GetSearchParamsTypeTrack {
+		return "Enter open · f favorite · v mark · l add to library"
+	}
+	return "Enter open"
+}
+
+func (p *resultsPanel) list(kind api.GetSearchParamsType) resultList {
+	switch kind {
+	case api.GetSearchParamsTypeAlbum:
+		return p.albums
+	case api.GetSearchParamsTypeArtist:
+		return p.artists
+	}
+	return p.tracks
+}
+
+// searching reports whether the latest search has yet to answer
+func (p *resultsPanel) searching() bool {
+	return p.list(p.kind).pending()
+}
+
+func (p *resultsPanel) update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case searchMsg:
+		return p.search(msg)
+
+	case pageMsg[api.Track], pageMsg[api.Album], pageMsg[api.Artist]:
+		var cmds []tea.Cmd
+		for _, l := range []resultList{p.tracks, p.albums, p.artists} {
+			cmds = append(cmds, l.update(msg))
+		}
+		p.settle()
+		return tea.Batch(cmds...)
+
+	case spinner.TickMsg:
+		if !p.searching() {
+			return nil
+		}
+		var cmd tea.Cmd
+		p.spinner, cmd = p.spinner.Update(msg)
+		return cmd
+
+	case tea.KeyMsg:
+		if p.actionOpen {
+			return p.updateActions(msg)
+		}
+		list := p.list(p.shown)
+		if p.shown == api.GetSearchParamsTypeTrack && list.len() > 0 {
+			switch msg.String() {
+			case "f":
+				return intent(toggleFavoriteMsg(p.tracks.selected()))
+			case "v":
+				p.picks.toggle(p.tracks.selected())
+				return nil
+			case "l":
+				return p.picks.addToLibrary(p.tracks.items, p.tracks.cursor)
+			}
+		}
+		if msg.String() != "enter" {
+			return list.update(msg)
+		}
+		if list.len() == 0 {
+			return nil
+		}
+		switch p.shown {
+		case api.GetSearchParamsTypeAlbum:
+			return intent(openAlbumMsg(models.Value(p.albums.selected().Id)))
+		case api.GetSearchParamsTypeArtist:
+			return intent(openArtistMsg(models.Value(p.artists.selected().Id)))
+		default:
+			p.actionOpen = true
+			p.actionCursor = 0
+		}
+	}
+	return nil
+}
+
+// search starts a search, superseding any still running
+func (p *resultsPanel) search(msg searchMsg) tea.Cmd {
+	if msg.query == p.query && msg.kind == p.kind && p.list(p.kind).failed() == "" {
+		return nil // already shown, or on its way
+	}
+	searching := p.searching()
+	p.query, p.kind = msg.query, msg.kind
+	if msg.query == "" {
+		p.tracks.clear()
+		p.albums.clear()
+		p.artists.clear()
+		p.actionOpen = false
+		return nil
+	}
+
+	var load tea.Cmd
+	switch msg.kind {
+	case api.GetSearchParamsTypeAlbum:
+		load = p.albums.reset(pager.New(search.Albums(msg.query), 0))
+	case api.GetSearchParamsTypeArtist:
+		load = p.artists.reset(pager.New(search.Artists(msg.query), 0))
+	default:
+		load = p.tracks.reset(pager.New(search.Tracks(msg.query), 0))
+	}
+	if searching {
+		return load // the spinner is still going
+	}
+	return tea.Batch(p.spinner.Tick, load)
+}
+
+// settle shows the latest search once it answered, and drops the results
+// it replaces
+func (p *resultsPanel) settle() {
+	if p.shown == p.kind || p.searching() {
+		return
+	}
+	p.list(p.shown).clear()
+	p.shown, p.actionOpen, p.picks = p.kind, false, nil
+}
+
+func (p *resultsPanel) updateActions(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "up", "k":
+		if p.actionCursor > 0 {
+			p.actionCursor--
+		}
+	case "down", "j":
+		if p.actionCursor < len(trackActions)-1 {
+			p.actionCursor++
+		}
+	case "enter":
+		p.actionOpen = false
+		t := p.tracks.selected()
+		switch trackActions[p.actionCursor] {
+		case "Play":
+			return intent(playMsg{tracks: []api.Track{t}})
+		case "Download":
+			return intent(downloadTrackMsg(t))
+		case "Add to queue", "Play next":
+			next := trackActions[p.actionCursor] == "Play next"
+			return intent(enqueueMsg{tracks: []api.Track{t}, next: next, what: models.Value(t.Title)})
+		case "Add to library…":
+			return intent(addToLibraryMsg{tracks: []api.Track{t}, what: models.Value(t.Title)})
+		}
+	case "esc":
+		p.actionOpen = false
+	}
+	return nil
+}
+
+func (p *resultsPanel) view(width, height int) string {
+	// While searching, the last results stay under the spinner
+	latest := p.list(p.kind)
+	var out []string
+	switch {
+	case p.searching():
+		out = append(out, fmt.Sprintf("Searching for %q %s", p.query, p.spinner.View()))
+	case latest.failed() != "" && !latest.ready():
+		return "[ERROR] " + latest.failed()
+	case p.query == "":
+		return itemStyle.Render("Start typing in the search bar.")
+	case latest.len() == 0:
+		return fmt.Sprintf("No %ss found for %q.", p.kind, p.query)
+	}
+
+	shown := p.list(p.shown)
+	status := shown.status()
+	rows := height - len(out)
+	if status != "" {
+		rows--
+	}
+	if p.actionOpen {
+		rows -= len(trackActions)
+	}
+	switch p.shown {
+	case api.GetSearchParamsTypeAlbum:
+		out = append(out, p.albums.rows(rows, func(i int, a api.Album, selected bool) string {
+			return listLine(fmt.Sprintf("%2d. %s - %s", i+1, models.Value(a.Title), models.Value(a.Artist)), selected)
+		})...)
+	case api.GetSearchParamsTypeArtist:
+		out = append(out, p.artists.rows(rows, func(i int, a api.Artist, selected bool) string {
+			return listLine(fmt.Sprintf("%2d. %s", i+1, models.Value(a.Name)), selected)
+		})...)
+	default:
+		out = append(out, p.tracks.rows(rows, func(i int, t api.Track, selected bool) string {
+			line := p.np.trackLine(t, fmt.Sprintf("%2d. %s - %s%s", i+1, models.Value(t.Title), models.Value(t.Artist), heart(p.favs, t)+p.picks.mark(t)), selected)
+			if selected && p.actionOpen {
+				line += "\n" + p.viewActions()
+			}
+			return line
+		})...)
+	}
+	if status != "" {
+		out = append(out, tabStyle.Render(status))
+	}
+	return lines(out)
+}
+
+func (p *resultsPanel) viewActions() string {
+	var out []string
+	for j, act := range trackActions {
+		if p.actionCursor == j {
+			out = append(out, selectedItemStyle.Render(" > "+act))
+		} else {
+			out = append(out, itemStyle.Render("   "+act))
+		}
+	}
+	return lines(out)
+}
+
+// listLine renders a row of a list, marking the cursor
+func listLine(line string, selected bool) string {
+	if selected {
+		return selectedItemStyle.Render("> " + line)
+	}
+	return itemStyle.Render(line)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/search"
+)
+
+// searchDebounce is how long typing has to pause before the results follow
+const searchDebounce = 300 * time.Millisecond
+
+// searchDebounceMsg fires once an edit has settled; edit tells whether
+// a later one superseded it
+type searchDebounceMsg struct{ edit int }
+
+// searchPanel is the search bar: a query and the kind of result wanted.
+// The results follow as you type.
+type searchPanel struct {
+	query string
+	kind  int // index into search.Kinds
+	edit  int // counts edits, to drop debounces of superseded ones
+}
+
+func (p *searchPanel) title() string { return "Search" }
+func (p *searchPanel) typing() bool  { return true }
+
+func (p *searchPanel) help() string {
+	return "type to search · ←/→ track/album/artist · Enter/Esc results"
+}
+
+// search asks the results panel for the current query
+func (p *searchPanel) search() tea.Cmd {
+	return intent(searchMsg{query: p.query, kind: search.Kinds[p.kind]})
+}
+
+func (p *searchPanel) update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case searchDebounceMsg:
+		if msg.edit != p.edit {
+			return nil
+		}
+		return p.search()
+	case tea.KeyMsg:
+		return p.updateKey(msg)
+	}
+	return nil
+}
+
+func (p *searchPanel) updateKey(key tea.KeyMsg) tea.Cmd {
+	switch key.Type {
+	case tea.KeyRunes:
+		p.query += string(key.Runes)
+	case tea.KeySpace:
+		p.query += " "
+	case tea.KeyBackspace:
+		if len(p.query) == 0 {
+			return nil
+		}
+		p.query = p.query[:len(p.query)-1]
+	case tea.KeyLeft:
+		p.kind = (p.kind + len(search.Kinds) - 1) % len(search.Kinds)
+	case tea.KeyRight:
+		p.kind = (p.kind + 1) % len(search.Kinds)
+	case tea.KeyEnter:
+		// Search right away, without waiting for the debounce
+		p.edit++
+		return tea.Batch(p.search(), intent(focusMsg(resultsPanelID)))
+	case tea.KeyEsc:
+		return intent(focusMsg(resultsPanelID))
+	default:
+		return nil
+	}
+
+	p.edit++
+	edit := p.edit
+	return tea.Tick(searchDebounce, func(time.Time) tea.Msg {
+		return searchDebounceMsg{edit: edit}
+	})
+}
+
+func (p *searchPanel) view(width, height int) string {
+	kinds := ""
+	for i, k := range search.Kinds {
+		if i == p.kind {
+			kinds += panelTitleStyle.Render(fmt.Sprintf("[%s]", k)) + " "
+		} else {
+			kinds += tabStyle.Render(fmt.Sprintf(" %s ", k)) + " "
+		}
+	}
+	return kinds + " " + p.query + "█"
+}
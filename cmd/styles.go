@@ -3,13 +3,6 @@ package cmd
 import "github.com/charmbracelet/lipgloss"
 
 var (
-	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#FFCC00")).
-			MarginTop(1).
-			MarginBottom(1).
-			MarginLeft(2)
-
 	itemStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#FFFFFF")).
 			PaddingLeft(4)
@@ -19,6 +12,26 @@ var (
 				Foreground(lipgloss.Color("#00FFAA")).
 				PaddingLeft(2)
 
+	// playingItemStyle marks the track that is playing in any list
+	playingItemStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#1DB954")).
+				PaddingLeft(2)
+
+	panelStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#444444")).
+			Padding(0, 1)
+
+	focusedPanelStyle = panelStyle.
+				BorderForeground(lipgloss.Color("#FFCC00"))
+
+	panelTitleStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FFCC00"))
+
+	tabStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#777777"))
+
 	helpStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#777777")).
 			MarginTop(1).
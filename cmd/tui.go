@@ -5,355 +5,366 @@ import (
 	"os"
 	"strings"
 
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/config"
 	"github.com/adityadeshmukh1/dab-cli/internal/download"
-	"github.com/adityadeshmukh1/dab-cli/internal/login"
+	"github.com/adityadeshmukh1/dab-cli/internal/favorites"
 	"github.com/adityadeshmukh1/dab-cli/internal/play"
-	"github.com/adityadeshmukh1/dab-cli/internal/search"
+	"github.com/adityadeshmukh1/dab-cli/internal/queue"
 
-	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-type model struct {
-	choices  []string
-	cursor   int
-	selected map[int]struct{}
-
-	// TUI login state
-	email     string
-	password  string
-	loggedIn  bool
-	errMsg    string
-	loginStep int // 0 = not started, 1 = email, 2 = password
-
-	// Search Song State
-	searchStep   int // 0 = not started, 1 = entering query, 2 = displaying results
-	searchQuery  string
-	searchResult []search.Track
-	searchErr    string
-	searching    bool // whether search is in progress
-
-	// Search action submenu state
-	searchActionOpen   bool // whether submenu (Play/Download) is open
-	searchActionCursor int  // cursor for submenu (0=Play, 1=Download)
-
-	// Download state
-	downloadStep    int
-	downloadInput   string
-	downloadMessage string
-
-	// Play Song State
-	playStep    int
-	playInput   string
-	playErr     string
-	playQuality string
-
-	spinner spinner.Model
+// panelID identifies a panel; the order is the tab order
+type panelID int
+
+const (
+	searchPanelID panelID = iota
+	resultsPanelID
+	detailPanelID
+	favoritesPanelID
+	librariesPanelID
+	queuePanelID
+	downloadsPanelID
+	nowPlayingPanelID
+	panelCount
+)
+
+// panelSlots places each panel in the layout
+var panelSlots = [panelCount]slot{
+	searchPanelID:     topSlot,
+	resultsPanelID:    leftSlot,
+	detailPanelID:     leftSlot,
+	favoritesPanelID:  leftSlot,
+	librariesPanelID:  leftSlot,
+	queuePanelID:      rightSlot,
+	downloadsPanelID:  rightSlot,
+	nowPlayingPanelID: bottomSlot,
 }
 
-func initialModel() model {
-	s := spinner.New()
-	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
-	return model{
-		choices:  []string{"Search Songs", "Login", "Quit"},
-		selected: make(map[int]struct{}),
-		spinner:  s,
+// panel is one pane of the TUI, a Bubble Tea model of its own. A panel
+// gets keys while it has focus and every other message; it reaches the
+// other panels only through the intent messages below.
+type panel interface {
+	title() string
+	update(msg tea.Msg) tea.Cmd
+	view(width, height int) string
+	help() string
+	typing() bool // whether keys go into a text field
+}
+
+// Intent messages, sent by one panel for another to act on
+type (
+	focusMsg  panelID // also tells the panel it got focus
+	statusMsg string  // shown in the status line
+
+	// searchMsg runs a search and shows it in the results
+	searchMsg struct {
+		query string
+		kind  api.GetSearchParamsType
+	}
+	openAlbumMsg  string // album ID
+	openArtistMsg string // artist ID
+
+	// playMsg plays tracks back to back from index
+	playMsg struct {
+		tracks []api.Track
+		index  int
 	}
+	// playQueueMsg plays the queue from a position
+	playQueueMsg int
+	// enqueueMsg adds tracks to the queue, after the current track if next
+	enqueueMsg struct {
+		tracks []api.Track
+		next   bool
+		what   string
+	}
+	downloadTrackMsg api.Track
+	downloadAlbumMsg api.Album
+	// toggleFavoriteMsg adds a track to the favorites, or removes it
+	toggleFavoriteMsg api.Track
+	// addToLibraryMsg asks which library to add tracks to
+	addToLibraryMsg struct {
+		tracks []api.Track
+		what   string
+	}
+
+	// loggedInMsg tells the panels the account changed
+	loggedInMsg struct{}
+)
+
+// intent returns a command that delivers msg
+func intent(msg tea.Msg) tea.Cmd {
+	return func() tea.Msg { return msg }
 }
 
-type searchResultsMsg struct {
-	tracks []search.Track
-	err    error
+// status returns a command that shows a status line, or an error
+func status(text string, err error) tea.Cmd {
+	if err != nil {
+		return intent(statusMsg("[ERROR] " + err.Error()))
+	}
+	return intent(statusMsg(text))
 }
 
-func doSearch(query string) tea.Cmd {
+// action runs fn in the background and reports done, or its error, in
+// the status line
+func action(done string, fn func() error) tea.Cmd {
 	return func() tea.Msg {
-		tracks, err := search.Search(query)
-		return searchResultsMsg{tracks: tracks, err: err}
+		if err := fn(); err != nil {
+			return statusMsg("[ERROR] " + err.Error())
+		}
+		return statusMsg(done)
+	}
+}
+
+type model struct {
+	panels     []panel
+	nowPlaying *nowPlaying
+	queueSync  *queue.Syncer // nil when the queue stays local
+	downloads  *download.Manager
+
+	focus         focus
+	width, height int
+	split         int // percent of the width for the left slot
+	status        string
+	login         *loginForm // open while logging in
+}
+
+func initialModel() model {
+	// Unfinished downloads from the last session start right away
+	mgr, err := download.NewManager(download.Configured(), config.Active().Downloads.Workers)
+	downloadsErr := ""
+	if err != nil {
+		downloadsErr = err.Error()
+		mgr = nil
+	}
+
+	q, err := queue.Load()
+	queueErr := ""
+	var syncer *queue.Syncer
+	if err != nil {
+		queueErr = err.Error()
+		q = nil
+	} else if queueSync() {
+		syncer = queue.NewSyncer(q, queue.DefaultSyncDelay)
+	}
+
+	// Cached favorites show their hearts before the server answers
+	favs, err := favorites.LoadCache()
+	favoritesErr := ""
+	if err != nil {
+		// Start over rather than save on top of the unreadable file
+		favoritesErr = err.Error()
+		if favs, err = favorites.ResetCache(); err != nil {
+			favoritesErr += "; " + err.Error()
+		} else {
+			favoritesErr += "; moved it to " + favorites.CacheFile() + ".bad"
+		}
+	}
+
+	playerOpts := play.Configured()
+	np := newNowPlaying(q, playerOpts.Quality)
+	if p, err := play.New(playerOpts); err != nil {
+		np.playerErr = err.Error()
+	} else {
+		np.player = p
+	}
+
+	panels := make([]panel, panelCount)
+	panels[searchPanelID] = &searchPanel{}
+	panels[resultsPanelID] = newResultsPanel(np, favs)
+	panels[detailPanelID] = newDetailPanel(np, favs)
+	panels[favoritesPanelID] = newFavoritesPanel(np, favs, favoritesErr)
+	panels[librariesPanelID] = newLibrariesPanel(np, favs)
+	panels[queuePanelID] = &queuePanel{queue: q, sync: syncer, err: queueErr, np: np, favs: favs}
+	panels[downloadsPanelID] = newDownloadsPanel(mgr, downloadsErr)
+	panels[nowPlayingPanelID] = np
+
+	return model{
+		panels:     panels,
+		nowPlaying: np,
+		queueSync:  syncer,
+		downloads:  mgr,
+		focus:      newFocus(),
+		width:      defaultWidth,
+		height:     defaultHeight,
+		split:      defaultSplit,
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return m.spinner.Tick
+	if m.queueSync != nil {
+		m.queueSync.Now() // pull what other devices queued
+	}
+	return tea.Batch(waitForDownload(m.downloads), waitForQueueSync(m.queueSync),
+		waitForPlayer(m.nowPlaying.player), loadFavorites())
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
 
-	// Handle spinner tick messages
-	case spinner.TickMsg:
-		if m.searching {
-			var cmd tea.Cmd
-			m.spinner, cmd = m.spinner.Update(msg)
-			return m, cmd
-		}
+	case statusMsg:
+		m.status = string(msg)
 		return m, nil
 
-	// Async search results
-	case searchResultsMsg:
-		m.searching = false
-		if msg.err != nil {
-			m.searchErr = msg.err.Error()
-			m.searchResult = nil
-		} else {
-			m.searchResult = msg.tracks
+	case focusMsg:
+		m.focus.set(panelID(msg))
+
+	case loginMsg:
+		return m.loggedIn(msg)
+
+	case loggedInMsg:
+		if m.queueSync != nil {
+			m.queueSync.Now()
 		}
-		return m, nil
 
 	case tea.KeyMsg:
-		// Quit
-		switch msg.String() {
-		case "ctrl+c", "q":
-			return m, tea.Quit
-		}
+		return m.updateKey(msg)
+	}
 
-		// -------------------
-		// LOGIN HANDLER
-		// -------------------
-		if m.loginStep > 0 {
-			switch msg.Type {
-			case tea.KeyRunes:
-				if m.loginStep == 1 {
-					m.email += string(msg.Runes)
-				} else if m.loginStep == 2 {
-					m.password += string(msg.Runes)
-				}
-			case tea.KeyBackspace:
-				if m.loginStep == 1 && len(m.email) > 0 {
-					m.email = m.email[:len(m.email)-1]
-				} else if m.loginStep == 2 && len(m.password) > 0 {
-					m.password = m.password[:len(m.password)-1]
-				}
-			case tea.KeyEnter:
-				if m.loginStep == 1 {
-					m.loginStep = 2
-				} else if m.loginStep == 2 {
-					err := login.Login(m.email, m.password)
-					if err != nil {
-						m.errMsg = err.Error()
-						m.loggedIn = false
-					} else {
-						m.loggedIn = true
-						m.errMsg = ""
-					}
-					m.loginStep = 0
-					m.email = ""
-					m.password = ""
-				}
-			}
-			return m, nil
-		}
+	// Everything else goes to every panel; each picks what it handles
+	var cmds []tea.Cmd
+	for _, p := range m.panels {
+		cmds = append(cmds, p.update(msg))
+	}
+	return m, tea.Batch(cmds...)
+}
 
-		// -------------------
-		// SEARCH HANDLER
-		// -------------------
-		if m.searchStep > 0 {
-			switch msg.Type {
-
-			case tea.KeyRunes:
-				if !m.searchActionOpen && !m.searching {
-					for _, r := range msg.Runes {
-						m.searchQuery += string(r)
-					}
-				}
-			case tea.KeySpace:
-				if !m.searchActionOpen && !m.searching {
-					m.searchQuery += " "
-				}
-
-			case tea.KeyBackspace:
-				if !m.searchActionOpen && !m.searching && len(m.searchQuery) > 0 {
-					m.searchQuery = m.searchQuery[:len(m.searchQuery)-1]
-				}
-			case tea.KeyEnter:
-				if m.searchStep == 1 {
-					// start search
-					m.cursor = 0
-					m.searchResult = nil
-					m.searchErr = ""
-					m.searchStep = 2
-					m.searching = true
-
-					return m, tea.Batch(
-						m.spinner.Tick, // Use the spinner's built-in tick command
-						doSearch(m.searchQuery),
-					)
-				}
-			}
-
-			if m.searchStep == 2 && !m.searching {
-				if m.searchActionOpen {
-					// Submenu navigation
-					switch msg.String() {
-					case "up", "k":
-						if m.searchActionCursor > 0 {
-							m.searchActionCursor--
-						}
-					case "down", "j":
-						if m.searchActionCursor < 1 {
-							m.searchActionCursor++
-						}
-					case "enter":
-						selectedTrack := m.searchResult[m.cursor]
-						if m.searchActionCursor == 0 {
-							err := play.Play(m.cursor+1, "medium")
-							if err != nil {
-								m.playErr = err.Error()
-							}
-						} else if m.searchActionCursor == 1 {
-							if download.Download(m.cursor + 1) {
-								m.downloadMessage = fmt.Sprintf("Track %d (%s) downloaded successfully!", m.cursor+1, selectedTrack.Title)
-							} else {
-								m.downloadMessage = fmt.Sprintf("Failed to download track %d.", m.cursor+1)
-							}
-						}
-						m.searchActionOpen = false
-					case "esc":
-						m.searchActionOpen = false
-					}
-				} else {
-					// Main search result navigation
-					switch msg.String() {
-					case "up", "k":
-						if m.cursor > 0 {
-							m.cursor--
-						}
-					case "down", "j":
-						if m.cursor < len(m.searchResult)-1 {
-							m.cursor++
-						}
-					case "enter":
-						m.searchActionOpen = true
-						m.searchActionCursor = 0
-					case "esc":
-						// back to menu
-						m.searchStep = 0
-						m.searchQuery = ""
-						m.searchResult = nil
-					}
-				}
-			}
-			return m, nil
-		}
+func (m model) updateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "ctrl+c" {
+		return m, m.nowPlaying.quit()
+	}
+	if m.login != nil {
+		return m.updateLogin(msg)
+	}
+
+	// Focus moves with tab even while typing
+	switch msg.String() {
+	case "tab":
+		return m.setFocus(1)
+	case "shift+tab":
+		return m.setFocus(-1)
+	}
 
-		// -------------------
-		// MAIN MENU
-		// -------------------
+	focused := m.panels[m.focus.current]
+	if !focused.typing() {
 		switch msg.String() {
-		case "up", "k":
-			if m.cursor > 0 {
-				m.cursor--
-			}
-		case "down", "j":
-			if m.cursor < len(m.choices)-1 {
-				m.cursor++
-			}
-		case "enter":
-			switch m.choices[m.cursor] {
-			case "Search Songs":
-				m.searchStep = 1
-				m.searchQuery = ""
-				m.searchResult = nil
-				m.searching = false
-			case "Play a song":
-				m.playStep = 1
-				m.playInput = ""
-				m.playErr = ""
-			case "Download a song":
-				m.downloadStep = 1
-				m.downloadInput = ""
-				m.downloadMessage = ""
-			case "Login":
-				m.loginStep = 1
-			case "Quit":
-				return m, tea.Quit
-			}
+		case "q":
+			return m, m.nowPlaying.quit()
+		case "<":
+			m.split = max(m.split-splitStep, minSplit)
+			return m, nil
+		case ">":
+			m.split = min(m.split+splitStep, maxSplit)
+			return m, nil
+		case "/":
+			m.focus.set(searchPanelID)
+			return m, nil
+		case "L":
+			m.login = &loginForm{}
+			return m, nil
+		}
+		if cmd, handled := m.nowPlaying.key(msg); handled {
+			return m, cmd
 		}
 	}
+	return m, focused.update(msg)
+}
 
-	return m, nil
+// setFocus moves focus along the tab order and tells the new panel
+func (m model) setFocus(step int) (tea.Model, tea.Cmd) {
+	m.focus.cycle(step)
+	return m, intent(focusMsg(m.focus.current))
 }
 
 func (m model) View() string {
-	// -------------------
-	// LOGIN VIEW
-	// -------------------
-	if m.loginStep > 0 {
-		s := "Login to DAB\n\n"
-		s += fmt.Sprintf("Email: %s\n", m.email)
-		if m.loginStep == 2 {
-			s += fmt.Sprintf("Password: %s\n", strings.Repeat("*", len(m.password)))
-		}
-		if m.errMsg != "" {
-			s += fmt.Sprintf("\n[ERROR] %s\n", m.errMsg)
-		}
-		s += "\nPress Enter to continue, Backspace to delete."
-		return s
+	if m.login != nil {
+		return m.viewLogin()
 	}
 
-	// -------------------
-	// SEARCH VIEW
-	// -------------------
-	if m.searchStep == 1 {
-		s := "Search for a track:\n\n"
-		s += m.searchQuery
-		s += "\n\nPress Enter to search, Backspace to delete."
-		return s
+	w, h := m.width, m.height
+	middle := max(h-searchHeight-nowPlayingHeight-footerHeight, 6)
+
+	top := m.viewPanel(searchPanelID, w, searchHeight)
+	var mid string
+	switch {
+	case w < narrowWidth && panelSlots[m.focus.current] == rightSlot:
+		mid = m.viewSlot(rightSlot, w, middle)
+	case w < narrowWidth:
+		mid = m.viewSlot(leftSlot, w, middle)
+	default:
+		left := w * m.split / 100
+		mid = lipgloss.JoinHorizontal(lipgloss.Top,
+			m.viewSlot(leftSlot, left, middle), m.viewSlot(rightSlot, w-left, middle))
 	}
-	if m.searchStep == 2 {
-		s := "Search Results:\n\n"
-		if m.searching {
-			s += fmt.Sprintf("Searching for %q %s\n", m.searchQuery, m.spinner.View())
-		} else if m.searchErr != "" {
-			s += fmt.Sprintf("[ERROR] %s\n", m.searchErr)
-		} else if len(m.searchResult) == 0 {
-			s += "No tracks found.\n"
-		} else {
-			for i, t := range m.searchResult {
-				if m.cursor == i {
-					s += selectedItemStyle.Render(fmt.Sprintf("> %2d. %s - %s", i+1, t.Title, t.Artist)) + "\n"
-					if m.searchActionOpen {
-						actions := []string{"Play", "Download"}
-						for j, act := range actions {
-							prefix := "   "
-							if m.searchActionCursor == j {
-								prefix = " > "
-								s += selectedItemStyle.Render(fmt.Sprintf("%s%s", prefix, act)) + "\n"
-							} else {
-								s += itemStyle.Render(fmt.Sprintf("%s%s", prefix, act)) + "\n"
-							}
-						}
-					}
-				} else {
-					s += itemStyle.Render(fmt.Sprintf("%2d. %s - %s", i+1, t.Title, t.Artist)) + "\n"
-				}
-			}
-		}
-		s += "\nUse up/down to navigate, Enter to select, Esc to go back."
-		return s
+	bottom := m.viewPanel(nowPlayingPanelID, w, nowPlayingHeight)
+
+	footer := m.status
+	if footer == "" {
+		footer = m.nowPlaying.status
 	}
+	help := m.panels[m.focus.current].help() + " · tab/shift+tab panels · / search · </> resize · L login · q quit"
+	return lipgloss.JoinVertical(lipgloss.Left, top, mid, bottom,
+		lipgloss.NewStyle().MaxWidth(w).Render(footer),
+		helpStyle.UnsetMargins().MaxWidth(w).Render(help))
+}
 
-	// -------------------
-	// MAIN MENU
-	// -------------------
-	s := titleStyle.Render("What do you want to do?") + "\n\n"
-	for i, choice := range m.choices {
-		if m.cursor == i {
-			s += selectedItemStyle.Render(fmt.Sprintf("> %s", choice)) + "\n"
-		} else {
-			s += itemStyle.Render(choice) + "\n"
+// viewSlot draws a shared slot with tabs for the panels that share it
+func (m model) viewSlot(s slot, width, height int) string {
+	shown := m.focus.left
+	if s == rightSlot {
+		shown = m.focus.right
+	}
+	var ids []panelID
+	for id, ps := range panelSlots {
+		if ps == s {
+			ids = append(ids, panelID(id))
 		}
 	}
-	s += "\nPress q to quit.\n"
-	return s
+	innerH := max(height-3, 1) // border and title line
+	content := m.panels[shown].view(max(width-4, 1), innerH)
+	return box(tabs(m.panels, ids, shown), content, width, height, m.focus.current == shown)
+}
+
+func (m model) viewPanel(id panelID, width, height int) string {
+	p := m.panels[id]
+	title := ""
+	innerH := max(height-2, 1)
+	if height > searchHeight {
+		title = panelTitleStyle.Render(p.title())
+		innerH--
+	}
+	return box(title, p.view(max(width-4, 1), innerH), width, height, m.focus.current == id)
 }
 
 func RunTUI() {
-	p := tea.NewProgram(initialModel())
-	if err := p.Start(); err != nil {
+	m := initialModel()
+	if m.downloads != nil {
+		defer m.downloads.Close()
+	}
+	if m.queueSync != nil {
+		defer m.queueSync.Close() // push edits still waiting for the debounce
+	}
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
 		fmt.Println("Error running program:", err)
 		os.Exit(1)
 	}
 }
+
+// countLabel is "1 track", "2 tracks"
+func countLabel(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// lines joins rows into a panel's content
+func lines(rows []string) string {
+	return strings.Join(rows, "\n")
+}
@@ -0,0 +1,418 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/favorites"
+	"github.com/adityadeshmukh1/dab-cli/internal/library"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+	"github.com/adityadeshmukh1/dab-cli/internal/output"
+	"github.com/adityadeshmukh1/dab-cli/internal/pager"
+	"github.com/adityadeshmukh1/dab-cli/internal/play"
+	"github.com/adityadeshmukh1/dab-cli/internal/queue"
+	"github.com/adityadeshmukh1/dab-cli/internal/search"
+)
+
+func favCommand() *cli.Command {
+	list := func(c *cli.Context) error {
+		favs, err := favorites.LoadCache()
+		if err != nil {
+			return fail(err)
+		}
+		tracks, err := favs.Refresh()
+		if err != nil {
+			return fail(err)
+		}
+		return render(c, output.Tracks(tracks))
+	}
+
+	return withFormat(&cli.Command{
+		Name:   "fav",
+		Usage:  "list and edit favorite tracks",
+		Action: list,
+		Subcommands: []*cli.Command{
+			withFormat(&cli.Command{Name: "list", Usage: "list favorite tracks", Action: list}),
+			{
+				Name:      "add",
+				Usage:     "add tracks to the favorites",
+				ArgsUsage: favUsage,
+				Flags:     []cli.Flag{trackFlag},
+				Action: func(c *cli.Context) error {
+					favs, tracks, err := favArgs(c)
+					if err != nil {
+						return err
+					}
+					for _, t := range tracks {
+						if err := favorites.Add(t); err != nil {
+							return fail(err)
+						}
+						if err := favs.Put(t); err != nil {
+							return fail(err)
+						}
+						fmt.Fprintf(c.App.Writer, "Added %s to favorites.\n", trackName(t))
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "rm",
+				Usage:     "remove tracks from the favorites",
+				ArgsUsage: favUsage,
+				Flags:     []cli.Flag{trackFlag},
+				Action: func(c *cli.Context) error {
+					favs, tracks, err := favArgs(c)
+					if err != nil {
+						return err
+					}
+					for _, t := range tracks {
+						if err := favorites.Remove(models.Value(t.Id)); err != nil {
+							return fail(err)
+						}
+						if err := favs.Drop(models.Value(t.Id)); err != nil {
+							return fail(err)
+						}
+						fmt.Fprintf(c.App.Writer, "Removed %s from favorites.\n", trackName(t))
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+const favUsage = "<number from last search>... | --id <track ID>"
+
+// favArgs loads the favorites cache and resolves the tracks fav add/rm
+// were given
+func favArgs(c *cli.Context) (*favorites.Cache, []api.Track, error) {
+	favs, err := favorites.LoadCache()
+	if err != nil {
+		return nil, nil, fail(err)
+	}
+	tracks, err := pickTracks(c, c.Args().Slice(), favUsage, favs.Tracks())
+	return favs, tracks, err
+}
+
+// pickTracks resolves the tracks a command acts on: numbers from the last
+// search, the trailing arguments args, or --id. A track given by ID is
+// looked up in known and the last search, since the server keeps whatever
+// track it is sent.
+func pickTracks(c *cli.Context, args []string, usage string, known []api.Track) ([]api.Track, error) {
+	if id := c.String("id"); id != "" {
+		last, _ := search.Last()
+		for _, t := range append(known, last...) {
+			if models.Value(t.Id) == id {
+				return []api.Track{t}, nil
+			}
+		}
+		return []api.Track{{Id: &id}}, nil
+	}
+
+	if err := needArgs(c, c.NArg()-len(args)+1, usage); err != nil {
+		return nil, err
+	}
+	last, err := search.Last()
+	if err != nil {
+		return nil, cli.Exit(err.Error(), exitError)
+	}
+	var tracks []api.Track
+	for _, arg := range args {
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, cli.Exit(fmt.Sprintf("%q is not a result number", arg), exitUsage)
+		}
+		if n < 1 || n > len(last) {
+			return nil, cli.Exit(fmt.Sprintf("track number %d not found in last search", n), exitNotFound)
+		}
+		tracks = append(tracks, last[n-1])
+	}
+	return tracks, nil
+}
+
+// trackName is a track's title, or its ID when the title is unknown
+func trackName(t api.Track) string {
+	if title := models.Value(t.Title); title != "" {
+		return title
+	}
+	return models.Value(t.Id)
+}
+
+func libCommand() *cli.Command {
+	list := func(c *cli.Context) error {
+		libs, err := library.List()
+		if err != nil {
+			return fail(err)
+		}
+		return render(c, output.Libraries(libs))
+	}
+
+	return withFormat(&cli.Command{
+		Name:   "lib",
+		Usage:  "list, show and edit libraries",
+		Action: list,
+		Subcommands: []*cli.Command{
+			withFormat(&cli.Command{Name: "list", Usage: "list libraries", Action: list}),
+			withPaging(withFormat(&cli.Command{
+				Name:      "show",
+				Usage:     "show a library's tracks",
+				ArgsUsage: "<library ID>",
+				Action: func(c *cli.Context) error {
+					if err := needArgs(c, 1, "<library ID>"); err != nil {
+						return err
+					}
+					page, limit, all, err := paging(c)
+					if err != nil {
+						return err
+					}
+					if limit == 0 {
+						limit = pager.DefaultLimit // later pages must line up with this one
+					}
+					id := c.Args().First()
+					lib, err := library.Get(id, page, limit)
+					if err != nil {
+						return fail(err)
+					}
+					if all {
+						p := pager.New(library.Tracks(id), limit)
+						p.Add(lib.Page())
+						tracks, err := p.All(context.Background())
+						if err != nil {
+							return fail(err)
+						}
+						whole := pager.Single(tracks)
+						lib.Tracks, lib.Pagination = whole.Items, whole.Pagination
+					}
+					if err := render(c, output.Library(*lib)); err != nil {
+						return err
+					}
+					pageHint(c, lib.Page())
+					return nil
+				},
+			})),
+			{
+				Name:      "create",
+				Usage:     "create a library",
+				ArgsUsage: "<name>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "description", Aliases: []string{"d"}, Usage: "what the library is for"},
+					&cli.BoolFlag{Name: "public", Usage: "let other users see it"},
+				},
+				Action: func(c *cli.Context) error {
+					if err := needArgs(c, 1, "<name>"); err != nil {
+						return err
+					}
+					name := strings.Join(c.Args().Slice(), " ")
+					lib, err := library.Create(name, c.String("description"), c.Bool("public"))
+					if err != nil {
+						return fail(err)
+					}
+					fmt.Fprintf(c.App.Writer, "Created library %s (%s).\n", models.Value(lib.Name), models.Value(lib.Id))
+					return nil
+				},
+			},
+			{
+				Name:      "edit",
+				Usage:     "rename or describe a library, or make it public or private",
+				ArgsUsage: "<library ID>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "name", Usage: "new name"},
+					&cli.StringFlag{Name: "description", Aliases: []string{"d"}, Usage: "new description; empty to clear it"},
+					&cli.BoolFlag{Name: "public", Usage: "let other users see it"},
+					&cli.BoolFlag{Name: "private", Usage: "hide it from other users"},
+				},
+				Action: func(c *cli.Context) error {
+					if err := needArgs(c, 1, "<library ID>"); err != nil {
+						return err
+					}
+					changes, err := libChanges(c)
+					if err != nil {
+						return err
+					}
+					id := c.Args().First()
+					if err := library.Update(id, changes); err != nil {
+						return fail(err)
+					}
+					fmt.Fprintf(c.App.Writer, "Updated library %s.\n", id)
+					return nil
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "delete a library",
+				ArgsUsage: "<library ID>",
+				Action: func(c *cli.Context) error {
+					if err := needArgs(c, 1, "<library ID>"); err != nil {
+						return err
+					}
+					id := c.Args().First()
+					if err := library.Delete(id); err != nil {
+						return fail(err)
+					}
+					fmt.Fprintf(c.App.Writer, "Deleted library %s.\n", id)
+					return nil
+				},
+			},
+			{
+				Name:      "add",
+				Usage:     "add tracks from the last search to a library",
+				ArgsUsage: libAddUsage,
+				Flags:     []cli.Flag{trackFlag},
+				Action: func(c *cli.Context) error {
+					if err := needArgs(c, 1, libAddUsage); err != nil {
+						return err
+					}
+					tracks, err := pickTracks(c, c.Args().Tail(), libAddUsage, nil)
+					if err != nil {
+						return err
+					}
+					id := c.Args().First()
+					for _, t := range tracks {
+						if err := library.AddTrack(id, t); err != nil {
+							return fail(err)
+						}
+						fmt.Fprintf(c.App.Writer, "Added %s to library %s.\n", trackName(t), id)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "rm",
+				Usage:     "remove tracks from a library",
+				ArgsUsage: libRmUsage,
+				Flags:     []cli.Flag{trackFlag},
+				Action: func(c *cli.Context) error {
+					if err := needArgs(c, 1, libRmUsage); err != nil {
+						return err
+					}
+					id := c.Args().First()
+					tracks, err := libTracks(c, id)
+					if err != nil {
+						return err
+					}
+					for _, t := range tracks {
+						if err := library.RemoveTrack(id, models.Value(t.Id)); err != nil {
+							return fail(err)
+						}
+						fmt.Fprintf(c.App.Writer, "Removed %s from library %s.\n", trackName(t), id)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "play",
+				Usage:     "play every track of a library",
+				ArgsUsage: "<library ID>",
+				Flags: []cli.Flag{
+					qualityFlag,
+					playerFlag,
+				},
+				Action: func(c *cli.Context) error {
+					if err := needArgs(c, 1, "<library ID>"); err != nil {
+						return err
+					}
+					o, err := playerOptions(c)
+					if err != nil {
+						return err
+					}
+					tracks, err := library.All(context.Background(), c.Args().First())
+					if err != nil {
+						return fail(err)
+					}
+					for _, t := range tracks {
+						fmt.Fprintf(c.App.Writer, "Playing %s - %s\n", models.Value(t.Title), models.Value(t.Artist))
+						if err := play.PlayTrackWith(o, models.Value(t.Id)); err != nil {
+							return fail(err)
+						}
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "queue",
+				Usage:     "queue every track of a library",
+				ArgsUsage: "<library ID>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "next", Usage: "play the library after the current track instead of at the end"},
+				},
+				Action: func(c *cli.Context) error {
+					if err := needArgs(c, 1, "<library ID>"); err != nil {
+						return err
+					}
+					tracks, err := library.All(context.Background(), c.Args().First())
+					if err != nil {
+						return fail(err)
+					}
+					err = editQueue(c, func(q *queue.Queue) error {
+						if c.Bool("next") {
+							return q.InsertNext(tracks...)
+						}
+						return q.Enqueue(tracks...)
+					})
+					if err == nil {
+						fmt.Fprintf(c.App.Writer, "Queued %s from library %s.\n", countLabel(len(tracks), "track"), c.Args().First())
+					}
+					return err
+				},
+			},
+		},
+	})
+}
+
+const (
+	libAddUsage = "<library ID> <number from last search>... | <library ID> --id <track ID>"
+	libRmUsage  = "<library ID> <number in the library>... | <library ID> --id <track ID>"
+)
+
+// libChanges collects the edits lib edit was given
+func libChanges(c *cli.Context) (api.PatchLibrariesIdJSONRequestBody, error) {
+	var changes api.PatchLibrariesIdJSONRequestBody
+	if c.IsSet("name") {
+		changes.Name = models.Ptr(c.String("name"))
+	}
+	if c.IsSet("description") {
+		changes.Description = models.Ptr(c.String("description"))
+	}
+	switch {
+	case c.Bool("public") && c.Bool("private"):
+		return changes, cli.Exit("--public and --private contradict each other", exitUsage)
+	case c.Bool("public"), c.Bool("private"):
+		changes.IsPublic = models.Ptr(c.Bool("public"))
+	}
+	if changes == (api.PatchLibrariesIdJSONRequestBody{}) {
+		return changes, cli.Exit("Nothing to change: give --name, --description, --public or --private", exitUsage)
+	}
+	return changes, nil
+}
+
+// libTracks resolves the tracks lib rm acts on: numbers as lib show lists
+// them, or --id
+func libTracks(c *cli.Context, id string) ([]api.Track, error) {
+	if trackID := c.String("id"); trackID != "" {
+		return []api.Track{{Id: &trackID}}, nil
+	}
+	if err := needArgs(c, 2, libRmUsage); err != nil {
+		return nil, err
+	}
+	all, err := library.All(context.Background(), id)
+	if err != nil {
+		return nil, fail(err)
+	}
+	var tracks []api.Track
+	for _, arg := range c.Args().Tail() {
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, cli.Exit(fmt.Sprintf("%q is not a track number", arg), exitUsage)
+		}
+		if n < 1 || n > len(all) {
+			return nil, cli.Exit(fmt.Sprintf("track number %d not found in library %s", n, id), exitNotFound)
+		}
+		tracks = append(tracks, all[n-1])
+	}
+	return tracks, nil
+}
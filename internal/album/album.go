@@ -0,0 +1,40 @@
+package album
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/client"
+)
+
+// Get fetches an album with its tracklist. The public /album lookup is
+// tried first; albums only known to the server's database are served
+// from /album/{id}.
+func Get(albumID string) (*api.Album, error) {
+	c, err := client.New()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.GetAlbumWithResponse(context.Background(), &api.GetAlbumParams{AlbumId: albumID})
+	if err != nil {
+		return nil, fmt.Errorf("album request failed: %v", err)
+	}
+	if resp.StatusCode() == http.StatusOK && resp.JSON200 != nil && resp.JSON200.Album != nil {
+		return resp.JSON200.Album, nil
+	}
+
+	byID, err := c.GetAlbumIdWithResponse(context.Background(), albumID)
+	if err != nil {
+		return nil, fmt.Errorf("album request failed: %v", err)
+	}
+	if byID.StatusCode() != http.StatusOK {
+		return nil, client.ResponseError("album", byID.StatusCode(), byID.Body)
+	}
+	if byID.JSON200 == nil || byID.JSON200.Album == nil {
+		return nil, fmt.Errorf("album %s not found", albumID)
+	}
+	return byID.JSON200.Album, nil
+}
@@ -0,0 +1,164 @@
+package artist
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/client"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+)
+
+// Discography fetches an artist and all of their albums
+func Discography(artistID string) (*api.Artist, []api.Album, error) {
+	c, err := client.New()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := c.GetDiscographyWithResponse(context.Background(), &api.GetDiscographyParams{ArtistId: artistID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("discography request failed: %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, nil, client.ResponseError("discography", resp.StatusCode(), resp.Body)
+	}
+	if resp.JSON200 == nil || resp.JSON200.Artist == nil {
+		return nil, nil, fmt.Errorf("artist %s not found", artistID)
+	}
+
+	var albums []api.Album
+	if resp.JSON200.Albums != nil {
+		albums = *resp.JSON200.Albums
+	}
+	return resp.JSON200.Artist, albums, nil
+}
+
+// Release types. The API has no album type field, so it is inferred
+// from the track count the way most stores label releases.
+const (
+	TypeAlbum  = "Album"
+	TypeEP     = "EP"
+	TypeSingle = "Single"
+)
+
+// Album orderings
+const (
+	SortNewest = "newest"
+	SortOldest = "oldest"
+	SortTitle  = "title"
+)
+
+// SortOrders lists the orderings in the order the TUI cycles through them
+var SortOrders = []string{SortNewest, SortOldest, SortTitle}
+
+// Group is one release type's albums
+type Group struct {
+	Type   string
+	Albums []api.Album
+}
+
+// AlbumType classifies a release as an album, EP or single
+func AlbumType(a api.Album) string {
+	n := models.AlbumTrackCount(a)
+	switch {
+	case n == 0:
+		return TypeAlbum
+	case n <= 3:
+		return TypeSingle
+	case n <= 6:
+		return TypeEP
+	}
+	return TypeAlbum
+}
+
+// SortAlbums returns a sorted copy of albums. Release dates are ISO
+// formatted, so they sort as strings.
+func SortAlbums(albums []api.Album, order string) ([]api.Album, error) {
+	sorted := append([]api.Album(nil), albums...)
+	var less func(a, b api.Album) bool
+	switch order {
+	case "", SortNewest:
+		less = func(a, b api.Album) bool { return models.Value(a.ReleaseDate) > models.Value(b.ReleaseDate) }
+	case SortOldest:
+		less = func(a, b api.Album) bool { return models.Value(a.ReleaseDate) < models.Value(b.ReleaseDate) }
+	case SortTitle:
+		less = func(a, b api.Album) bool {
+			return strings.ToLower(models.Value(a.Title)) < strings.ToLower(models.Value(b.Title))
+		}
+	default:
+		return nil, fmt.Errorf("unknown sort order %q (want newest, oldest or title)", order)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+	return sorted, nil
+}
+
+// GroupAlbums splits sorted albums by release type: albums, then EPs,
+// then singles. Empty groups are left out.
+func GroupAlbums(albums []api.Album) []Group {
+	groups := []Group{{Type: TypeAlbum}, {Type: TypeEP}, {Type: TypeSingle}}
+	for _, a := range albums {
+		for i := range groups {
+			if groups[i].Type == AlbumType(a) {
+				groups[i].Albums = append(groups[i].Albums, a)
+			}
+		}
+	}
+
+	var nonEmpty []Group
+	for _, g := range groups {
+		if len(g.Albums) > 0 {
+			nonEmpty = append(nonEmpty, g)
+		}
+	}
+	return nonEmpty
+}
+
+// Similar resolves an artist's similarArtistIds into artists. There is no
+// single-artist endpoint, so each one is looked up through /discography.
+// Artists that fail to load are skipped; an error is only returned if
+// none could be loaded.
+func Similar(a api.Artist) ([]api.Artist, error) {
+	ids := models.Value(a.SimilarArtistIds)
+	found := make([]*api.Artist, len(ids))
+
+	var (
+		wg      sync.WaitGroup
+		lastErr error
+		mu      sync.Mutex
+	)
+	sem := make(chan struct{}, 4)
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			ar, _, err := Discography(id)
+			if err != nil {
+				mu.Lock()
+				lastErr = err
+				mu.Unlock()
+				return
+			}
+			found[i] = ar
+		}(i, id)
+	}
+	wg.Wait()
+
+	var similar []api.Artist
+	for _, ar := range found {
+		if ar != nil {
+			similar = append(similar, *ar)
+		}
+	}
+	if len(similar) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return similar, nil
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/config"
+)
+
+// New builds the typed API client every feature package goes through.
+// It targets the active config profile and attaches the session cookie
+// (if any) to each request.
+func New(opts ...api.ClientOption) (*api.ClientWithResponses, error) {
+	opts = append([]api.ClientOption{
+		api.WithBaseURL(config.BaseURL()),
+		api.WithRequestEditorFn(withSession),
+	}, opts...)
+	c, err := api.NewClientWithResponses(config.DefaultBaseURL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API client: %v", err)
+	}
+	return c, nil
+}
+
+// ResolveURL makes a URL from an API response absolute. Servers may hand
+// out links relative to themselves, e.g. "/covers/al1.png".
+func ResolveURL(ref string) (string, error) {
+	base, err := url.Parse(config.BaseURL())
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL: %v", err)
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %v", ref, err)
+	}
+	return base.ResolveReference(u).String(), nil
+}
+
+// Attach the stored session cookie. Public endpoints work without one,
+// so a missing session is not an error here.
+func withSession(ctx context.Context, req *http.Request) error {
+	token, err := LoadSession()
+	if err != nil {
+		return nil
+	}
+	req.AddCookie(&http.Cookie{Name: SessionCookie, Value: token})
+	return nil
+}
+
+// APIError is a non-2xx reply from the API
+type APIError struct {
+	Op      string
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s failed (%d): %s", e.Op, e.Status, e.Message)
+}
+
+// ResponseError turns a non-2xx reply into an *APIError, preferring the
+// message from the API's Error schema over the raw body
+func ResponseError(op string, status int, body []byte) error {
+	msg := strings.TrimSpace(string(body))
+
+	var apiErr api.Error
+	if err := json.Unmarshal(body, &apiErr); err == nil {
+		if apiErr.Message != nil && *apiErr.Message != "" {
+			msg = *apiErr.Message
+		} else if apiErr.Error != nil && *apiErr.Error != "" {
+			msg = *apiErr.Error
+		}
+	}
+	if msg == "" {
+		msg = http.StatusText(status)
+	}
+	return &APIError{Op: op, Status: status, Message: msg}
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/config"
+)
+
+const SessionCookie = "session"
+
+// LoadSession reads the session token saved by login
+func LoadSession() (string, error) {
+	data, err := os.ReadFile(config.SessionFile())
+	if err != nil {
+		return "", fmt.Errorf("could not read session file: %v", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("session file is empty")
+	}
+	return token, nil
+}
+
+// SaveSession persists the session token for later requests
+func SaveSession(token string) error {
+	if err := os.WriteFile(config.SessionFile(), []byte(token), 0600); err != nil {
+		return fmt.Errorf("failed to write session: %v", err)
+	}
+	return nil
+}
+
+// ClearSession forgets the stored session token
+func ClearSession() error {
+	if err := os.Remove(config.SessionFile()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove session: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultProfile = "prod"
+	DefaultBaseURL = "https://dab.yeet.su/api"
+)
+
+// Profile describes one DAB-compatible server
+type Profile struct {
+	BaseURL string `yaml:"base_url"`
+}
+
+// Downloads configures where downloaded files go. Empty fields fall
+// back to the download package's defaults.
+type Downloads struct {
+	Root     string `yaml:"root,omitempty"`      // directory downloads are written under
+	Template string `yaml:"template,omitempty"`  // Go template for the path below root, without extension
+	OnExists string `yaml:"on_exists,omitempty"` // skip, overwrite or suffix
+	Quality  string `yaml:"quality,omitempty"`   // server quality code
+	NoTags   bool   `yaml:"no_tags,omitempty"`   // skip writing tags and cover art
+	Workers  int    `yaml:"workers,omitempty"`   // parallel downloads in the TUI
+
+	Transcode []string                    `yaml:"transcode,omitempty"` // profiles to encode every download to
+	Profiles  map[string]TranscodeProfile `yaml:"profiles,omitempty"`  // user-defined transcode profiles
+}
+
+// TranscodeProfile defines an ffmpeg encoding, e.g.
+//
+//	opus-96: {codec: libopus, format: ogg, ext: .opus, bitrate: 96k}
+type TranscodeProfile struct {
+	Codec   string   `yaml:"codec"`
+	Format  string   `yaml:"format"` // ffmpeg muxer
+	Ext     string   `yaml:"ext,omitempty"`
+	Bitrate string   `yaml:"bitrate,omitempty"`
+	Quality string   `yaml:"quality,omitempty"` // VBR quality instead of a bitrate
+	Args    []string `yaml:"args,omitempty"`    // extra ffmpeg output options
+}
+
+// Queue configures the play queue
+type Queue struct {
+	NoSync bool `yaml:"no_sync,omitempty"` // keep the queue local, never syncing it with the server
+}
+
+// Player chooses how tracks are played
+type Player struct {
+	Backend string  `yaml:"backend,omitempty"` // mpv, ffplay or null
+	Output  string  `yaml:"output,omitempty"`  // null: write the fetched stream here
+	Speed   float64 `yaml:"speed,omitempty"`   // null: run the clock this much faster than real time
+	Quality string  `yaml:"quality,omitempty"` // transcode profile tracks are streamed through
+}
+
+// Config mirrors the on-disk config file
+type Config struct {
+	Profile   string             `yaml:"profile"`
+	Profiles  map[string]Profile `yaml:"profiles"`
+	Downloads Downloads          `yaml:"downloads,omitempty"`
+	Queue     Queue              `yaml:"queue,omitempty"`
+	Player    Player             `yaml:"player,omitempty"`
+}
+
+// Overrides come from the environment or command-line flags and win over the file
+type Overrides struct {
+	ConfigPath string
+	Profile    string
+	BaseURL    string
+}
+
+var (
+	active        = Default()
+	activeProfile = DefaultProfile
+	activeBaseURL = DefaultBaseURL
+	overridden    bool // the base URL is not the profile's own
+)
+
+// Default is the built-in config used when no file exists
+func Default() *Config {
+	return &Config{
+		Profile: DefaultProfile,
+		Profiles: map[string]Profile{
+			DefaultProfile: {BaseURL: DefaultBaseURL},
+		},
+	}
+}
+
+// Path returns where the config file lives, honouring $DAB_CONFIG
+func Path() string {
+	if p := os.Getenv("DAB_CONFIG"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "config.yaml"
+	}
+	return filepath.Join(dir, "dab-cli", "config.yaml")
+}
+
+// Load reads a config file, falling back to the defaults if it does not exist
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %v", err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %v", path, err)
+	}
+
+	// The built-in profile is always available unless the file redefines
+	// it; an empty "profiles:" leaves no map to add it to
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]Profile{}
+	}
+	if _, ok := cfg.Profiles[DefaultProfile]; !ok {
+		cfg.Profiles[DefaultProfile] = Profile{BaseURL: DefaultBaseURL}
+	}
+	if cfg.Profile == "" {
+		cfg.Profile = DefaultProfile
+	}
+	return cfg, nil
+}
+
+// Save writes the config file, creating its directory if needed
+func Save(path string, cfg *Config) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config dir: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config: %v", err)
+	}
+	return nil
+}
+
+// Init loads the config file and resolves the active server.
+// Precedence is flags, then $DAB_PROFILE / $DAB_BASE_URL, then the file.
+func Init(o Overrides) error {
+	if o.ConfigPath == "" {
+		o.ConfigPath = Path()
+	}
+	if o.Profile == "" {
+		o.Profile = os.Getenv("DAB_PROFILE")
+	}
+	if o.BaseURL == "" {
+		o.BaseURL = os.Getenv("DAB_BASE_URL")
+	}
+
+	cfg, err := Load(o.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	name := cfg.Profile
+	if o.Profile != "" {
+		name = o.Profile
+	}
+	profile, ok := cfg.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q (have: %v)", name, ProfileNames(cfg))
+	}
+
+	baseURL := profile.BaseURL
+	if o.BaseURL != "" {
+		baseURL = o.BaseURL
+	}
+	if baseURL == "" {
+		return fmt.Errorf("profile %q has no base_url", name)
+	}
+
+	active = cfg
+	activeProfile = name
+	activeBaseURL = baseURL
+	overridden = baseURL != profile.BaseURL
+	return nil
+}
+
+// Active returns the loaded config
+func Active() *Config {
+	return active
+}
+
+// ProfileName returns the name of the profile in use
+func ProfileName() string {
+	return activeProfile
+}
+
+// BaseURL returns the API base URL for the profile in use
+func BaseURL() string {
+	return activeBaseURL
+}
+
+// ProfileNames lists the configured profiles in a stable order
+func ProfileNames(cfg *Config) []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SessionFile keeps each server's login apart so a cookie for one
+// profile is never sent to another. A base URL given by flag or
+// environment gets a session of its own too, named after a hash of it.
+func SessionFile() string {
+	return ".session" + serverSuffix()
+}
+
+// ServerFile names a file of state kept per server the way SessionFile
+// is, e.g. ".dabcli_queue.json" becomes ".dabcli_queue-beta.json" for
+// the profile beta. The default profile keeps the name as given.
+func ServerFile(name string) string {
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + serverSuffix() + ext
+}
+
+// serverSuffix tells apart the files of the active server
+func serverSuffix() string {
+	suffix := ""
+	if activeProfile != DefaultProfile {
+		suffix += "-" + activeProfile
+	}
+	if overridden {
+		sum := sha256.Sum256([]byte(activeBaseURL))
+		suffix += fmt.Sprintf("-%x", sum[:6])
+	}
+	return suffix
+}
@@ -1,76 +1,464 @@
 package download
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/client"
+	"github.com/adityadeshmukh1/dab-cli/internal/config"
 	"github.com/adityadeshmukh1/dab-cli/internal/store"
+	"github.com/adityadeshmukh1/dab-cli/internal/transcode"
 )
 
-// Download stream to a file
-func downloadToFile(url, filename string) (string, error) {
-	// Fetch the audio stream
-	audioResp, err := http.Get(url)
+// DefaultQuality is the server's default download quality (hi-res FLAC
+// where available). "5" is MP3 320, "6" CD-quality FLAC, "7" 24-bit/96 kHz.
+const DefaultQuality = "27"
+
+// extensions maps audio content types to file extensions
+var extensions = map[string]string{
+	"audio/flac":   ".flac",
+	"audio/x-flac": ".flac",
+	"audio/mpeg":   ".mp3",
+	"audio/mp4":    ".m4a",
+	"audio/aac":    ".aac",
+	"audio/ogg":    ".ogg",
+	"audio/opus":   ".opus",
+	"audio/wav":    ".wav",
+	"audio/x-wav":  ".wav",
+}
+
+// extension picks a file extension from the response's content type,
+// then the URL path, falling back to .mp3
+func extension(resp *http.Response) string {
+	if ct, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		if ext, ok := extensions[ct]; ok {
+			return ext
+		}
+	}
+	if ext := path.Ext(resp.Request.URL.Path); ext != "" {
+		return ext
+	}
+	return ".mp3"
+}
+
+// Options control where and how downloads are written
+type Options struct {
+	Quality  string // server quality code
+	Root     string // directory downloads are written under
+	Template string // path template below Root, see Fields
+	OnExists string // Skip, Overwrite or Suffix
+	NoTags   bool   // leave files as served, without tags or cover art
+
+	// Transcode lists the profiles every track is encoded to, each a
+	// separate file. Original keeps the file as served; no profiles at
+	// all is the same as just Original.
+	Transcode []string
+}
+
+// Original names the untranscoded output in Options.Transcode
+const Original = "original"
+
+// Configured returns the options from the config file, with defaults
+// for anything left unset
+func Configured() Options {
+	d := config.Active().Downloads
+	o := Options{Quality: d.Quality, Root: d.Root, Template: d.Template, OnExists: d.OnExists, NoTags: d.NoTags, Transcode: d.Transcode}
+	if o.Quality == "" {
+		o.Quality = DefaultQuality
+	}
+	if o.Root == "" {
+		o.Root = "."
+	}
+	if o.OnExists == "" {
+		o.OnExists = Skip
+	}
+	return o
+}
+
+// Validate reports a bad template, collision policy or transcode
+// profile before anything is downloaded
+func (o Options) Validate() error {
+	tmpl, err := ParseTemplate(o.Template)
+	if err != nil {
+		return err
+	}
+	if _, err := tmpl.Path(Fields{}); err != nil {
+		return err
+	}
+	if _, err := o.outputs(); err != nil {
+		return err
+	}
+	for _, p := range Policies {
+		if o.OnExists == p || o.OnExists == "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown collision policy %q (want %s)", o.OnExists, strings.Join(Policies, ", "))
+}
+
+// outputs resolves the transcode profiles; nil stands for Original
+func (o Options) outputs() ([]*transcode.Profile, error) {
+	if len(o.Transcode) == 0 {
+		return []*transcode.Profile{nil}, nil
+	}
+	var out []*transcode.Profile
+	seen := map[string]bool{}
+	for _, name := range o.Transcode {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if name == Original {
+			out = append(out, nil)
+			continue
+		}
+		p, err := transcode.Lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &p)
+	}
+	if len(out) > 1 || out[0] != nil {
+		if err := transcode.Available(); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Result describes one downloaded track
+type Result struct {
+	Path    string
+	Bytes   int64
+	Skipped bool  // the file already existed and was kept
+	TagErr  error // the audio was saved but tagging it failed
+}
+
+// maxAttempts is how often a transfer is tried, resuming each time
+// from what the .part file already holds
+const maxAttempts = 3
+
+// run downloads a job into its .part file, verifies it, tags it and
+// renames it into place. The job stays in the journal until it is done,
+// so a failure can be resumed later.
+func (j *job) run() (Result, error) {
+	j.State, j.Error = Running, ""
+	j.Attempts++
+	res, err := j.transfer()
 	if err != nil {
-		return "", fmt.Errorf("failed to download audio: %v", err)
+		j.State, j.Error = Failed, err.Error()
+		record(j)
+		return res, err
+	}
+	j.State = Done
+	return res, nil
+}
+
+func (j *job) transfer() (Result, error) {
+	if prev, ok := journaled(j.root, j.Base); ok && prev.TrackID == j.TrackID && prev.Quality == j.Quality && sameProfile(prev.Profile, j.Profile) {
+		j.Path = prev.Path
+	}
+	if err := record(j); err != nil {
+		return Result{}, err
+	}
+
+	// Fetch stream URL
+	url, err := store.FetchStreamURLQuality(j.TrackID, j.Quality)
+	if err != nil {
+		return Result{}, fmt.Errorf("error in fetching the stream URL: %w", err)
+	}
+
+	var size int64
+	for attempt := 1; ; attempt++ {
+		var skipped bool
+		size, skipped, err = j.fetch(url)
+		if skipped {
+			return Result{Path: j.Path, Skipped: true}, forget(j)
+		}
+		if err == nil {
+			break
+		}
+		if attempt == maxAttempts {
+			return Result{Path: j.Path}, err
+		}
+		time.Sleep(time.Duration(attempt) * time.Second)
+	}
+
+	part := j.Path + partSuffix
+	if err := checkDuration(part, j.Duration); err != nil {
+		// A complete file of the wrong length will not get better by
+		// resuming, but it is kept for a look: the API's duration may be
+		// what is wrong
+		return Result{Path: j.Path}, fmt.Errorf("%v; kept as %s", err, part)
+	}
+
+	res := Result{Path: j.Path, Bytes: size}
+	if j.Profile != nil {
+		// The .part file is kept until the encode succeeds, so an
+		// interrupted encode is redone on resume without downloading again
+		encoded := j.Path + encodeSuffix
+		var meta map[string]string
+		if j.Tags != nil {
+			meta = metadata(*j.Tags)
+		}
+		if err := transcode.File(part, encoded, *j.Profile, meta); err != nil {
+			return Result{Path: j.Path}, err
+		}
+		os.Remove(part)
+		part = encoded
+		if info, err := os.Stat(part); err == nil {
+			res.Bytes = info.Size()
+		}
+	}
+	if j.Tags != nil {
+		res.TagErr = tag(part, *j.Tags, j.CoverURL)
+	}
+	if err := os.Rename(part, j.Path); err != nil {
+		return res, fmt.Errorf("failed to move download into place: %v", err)
+	}
+	return res, forget(j)
+}
+
+// fetch transfers the audio into the .part file, asking only for the
+// bytes it is missing. It returns the complete size, or skipped if the
+// collision policy keeps an existing file.
+func (j *job) fetch(url string) (size int64, skipped bool, err error) {
+	var offset int64
+	if j.Path != "" {
+		if info, err := os.Stat(j.Path + partSuffix); err == nil {
+			offset = info.Size()
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to download audio: %v", err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	audioResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to download audio: %v", err)
 	}
 	defer audioResp.Body.Close()
 
-	if audioResp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("bad download status: %d", audioResp.StatusCode)
+	switch audioResp.StatusCode {
+	case http.StatusOK:
+		// No range support, or nothing to resume: start over
+		offset, size = 0, audioResp.ContentLength
+	case http.StatusPartialContent:
+		start, total, ok := contentRange(audioResp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			return 0, false, fmt.Errorf("unexpected Content-Range %q", audioResp.Header.Get("Content-Range"))
+		}
+		size = total
+	case http.StatusRequestedRangeNotSatisfiable:
+		if _, total, ok := contentRange(audioResp.Header.Get("Content-Range")); ok && total == offset {
+			return offset, false, nil // the part file already holds everything
+		}
+		os.Remove(j.Path + partSuffix)
+		return 0, false, fmt.Errorf("partial download no longer matches the track, starting over")
+	default:
+		return 0, false, fmt.Errorf("bad download status: %d", audioResp.StatusCode)
 	}
 
-	// Ensure filename
-	if filename == "" {
-		filename = "track.mp3"
+	if j.Path == "" {
+		ext := extension(audioResp)
+		if j.Profile != nil {
+			ext = j.Profile.Ext
+		}
+		path, skip, err := resolve(j.Base+ext, j.OnExists)
+		if err != nil {
+			return 0, false, err
+		}
+		j.Path = path
+		if skip {
+			return 0, true, nil
+		}
+		if err := record(j); err != nil {
+			return 0, false, err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(j.Path), 0o755); err != nil {
+		return 0, false, fmt.Errorf("failed to create directory: %v", err)
 	}
-	outPath := filepath.Join(".", filename)
 
-	// Write stream to file
-	outFile, err := os.Create(outPath)
+	// Write stream to the part file
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	outFile, err := os.OpenFile(j.Path+partSuffix, flags, 0o644)
 	if err != nil {
-		return "", fmt.Errorf("failed to create file: %v", err)
+		return 0, false, fmt.Errorf("failed to create file: %v", err)
 	}
 	defer outFile.Close()
 
-	_, err = io.Copy(outFile, audioResp.Body)
+	var body io.Reader = audioResp.Body
+	if j.progress != nil {
+		j.progress(offset, size)
+		body = &progressReader{r: body, written: offset, total: size, report: j.progress}
+	}
+	n, err := io.Copy(outFile, body)
 	if err != nil {
-		return "", fmt.Errorf("failed to save audio: %v", err)
+		return 0, false, fmt.Errorf("failed to save audio: %v", err)
+	}
+	if size < 0 {
+		size = offset + n // length unknown: trust what arrived
+	}
+	if offset+n != size {
+		return 0, false, fmt.Errorf("download incomplete: got %d of %d bytes", offset+n, size)
 	}
+	return size, false, nil
+}
+
+// progressReader reports how much of a transfer has arrived
+type progressReader struct {
+	r       io.Reader
+	written int64
+	total   int64 // -1 if unknown
+	report  func(written, total int64)
+}
 
-	return outPath, nil
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.written += int64(n)
+	p.report(p.written, p.total)
+	return n, err
 }
 
-func Download(trackNumber int) bool {
-	// Load the last search results
-	if err := store.LoadFromFile(".dabcli_last_search.json"); err != nil {
-		fmt.Printf("Warning: could not load last search results: %v\n", err)
+// contentRange parses "bytes start-end/total" and "bytes */total"
+func contentRange(h string) (start, total int64, ok bool) {
+	if n, _ := fmt.Sscanf(h, "bytes */%d", &total); n == 1 {
+		return 0, total, true
 	}
+	var end int64
+	if n, _ := fmt.Sscanf(h, "bytes %d-%d/%d", &start, &end, &total); n == 3 {
+		return start, total, true
+	}
+	return 0, 0, false
+}
 
-	trackID, ok := store.GetSongID(trackNumber)
-	if !ok {
-		fmt.Printf("track number %d not found in last search", trackNumber)
-		return false
+// Track saves a track laid out by the path template and returns the
+// files it wrote, one per transcode profile. A track known only by its
+// ID is saved as "<ID>.ext".
+func Track(t api.Track, opts Options) ([]Result, error) {
+	jobs, err := trackJobs(t, opts)
+	if err != nil {
+		return nil, err
 	}
+	return runEach(jobs)
+}
 
-	// Fetch stream URL
-	url, err := store.FetchStreamURL(trackID)
+// runEach runs jobs in turn, stopping at the first failure
+func runEach(jobs []*job) ([]Result, error) {
+	results := make([]Result, 0, len(jobs))
+	for _, j := range jobs {
+		res, err := j.run()
+		if err != nil {
+			return results, err
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// Progress is reported before and after each track of an album download
+type Progress struct {
+	Index   int // 1-based
+	Total   int
+	Track   api.Track
+	Profile string // transcode profile, empty for the file as served
+	Done    bool
+	Result  Result
+	Err     error
+}
+
+// Summary is the outcome of an album download
+type Summary struct {
+	Album   api.Album
+	Saved   []string
+	Skipped []string
+	Failed  []api.Track
+	Bytes   int64
+}
+
+// Album downloads every track of an album, laid out by the path
+// template. A failed track is reported and skipped; the summary lists
+// what was saved.
+func Album(albumID string, opts Options, progress func(Progress)) (*Summary, error) {
+	a, jobs, err := albumJobs(albumID, opts)
+	if err != nil {
+		return nil, err
+	}
+	sum := runAll(jobs, progress)
+	sum.Album = *a
+	return sum, nil
+}
+
+// Resume finishes the downloads left unfinished under the download root
+func Resume(opts Options, progress func(Progress)) (*Summary, error) {
+	jobs, err := journalJobs(opts)
 	if err != nil {
-		fmt.Printf("Error in fetching the stream URL: %v", err)
-		return false
+		return nil, err
+	}
+	return runAll(jobs, progress), nil
+}
+
+// runAll downloads jobs one after another, reporting progress
+func runAll(jobs []*job, progress func(Progress)) *Summary {
+	if progress == nil {
+		progress = func(Progress) {}
 	}
 
-	// Download audio
-	outFile := "song.mp3"
-	savedFile, err := downloadToFile(url, outFile)
+	sum := &Summary{}
+	for i, j := range jobs {
+		p := Progress{Index: i + 1, Total: len(jobs), Track: j.track(), Profile: j.profile()}
+		progress(p)
+
+		p.Result, p.Err = j.run()
+		p.Done = true
+		switch {
+		case p.Err != nil:
+			sum.Failed = append(sum.Failed, p.Track)
+		case p.Result.Skipped:
+			sum.Skipped = append(sum.Skipped, p.Result.Path)
+		default:
+			sum.Saved = append(sum.Saved, p.Result.Path)
+			sum.Bytes += p.Result.Bytes
+		}
+		progress(p)
+	}
+	return sum
+}
+
+// albumInfo asks /download for the album and its tracklist
+func albumInfo(albumID, quality string) (*api.Album, error) {
+	c, err := client.New()
 	if err != nil {
-		fmt.Print(err)
-		return false
+		return nil, err
 	}
 
-	fmt.Printf("Track downloaded: %s\n", savedFile)
-	return true
+	params := &api.GetDownloadParams{AlbumId: albumID, Quality: &quality}
+	resp, err := c.GetDownloadWithResponse(context.Background(), params)
+	if err != nil {
+		return nil, fmt.Errorf("download request failed: %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, client.ResponseError("download", resp.StatusCode(), resp.Body)
+	}
+	if resp.JSON200 == nil || resp.JSON200.Album == nil {
+		return nil, fmt.Errorf("album %s not found", albumID)
+	}
+	return resp.JSON200.Album, nil
 }
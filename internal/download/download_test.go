@@ -0,0 +1,301 @@
+package download
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/mock/mocktest"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+	"github.com/adityadeshmukh1/dab-cli/internal/transcode"
+)
+
+// a440 is track t1 of the fixtures as a search would return it
+var a440 = api.Track{
+	Id:         models.Ptr("t1"),
+	Title:      models.Ptr("A440"),
+	Artist:     models.Ptr("Mock Orchestra"),
+	AlbumTitle: models.Ptr("Sine Waves"),
+	AlbumCover: models.Ptr("/covers/al1.png"),
+	Duration:   models.Ptr(95),
+}
+
+func testOptions(onExists string) Options {
+	return Options{Quality: DefaultQuality, Root: ".", Template: "{{.Title}}", OnExists: onExists, NoTags: true}
+}
+
+// audio is what the mock serves for a track at the default quality
+func audio(t *testing.T, base, id string) []byte {
+	t.Helper()
+	return audioAt(t, base, id, DefaultQuality)
+}
+
+func audioAt(t *testing.T, base, id, quality string) []byte {
+	t.Helper()
+	resp, err := http.Get(base + "/audio/" + id + "?quality=" + quality)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// saveOne downloads a track to a single file
+func saveOne(t *testing.T, tr api.Track, opts Options) Result {
+	t.Helper()
+	results, err := Track(tr, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	return results[0]
+}
+
+func TestAlbumSavesEveryTrack(t *testing.T) {
+	mocktest.Start(t)
+
+	opts := testOptions(Skip)
+	opts.Template = DefaultTemplate
+	var started, done int
+	sum, err := Album("al1", opts, func(p Progress) {
+		if p.Total != 3 {
+			t.Errorf("track %d of %d, want 3 in all", p.Index, p.Total)
+		}
+		if p.Done {
+			done++
+		} else {
+			started++
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started != 3 || done != 3 {
+		t.Errorf("progress reported %d starts and %d ends, want 3 of each", started, done)
+	}
+
+	dir := filepath.Join("Mock Orchestra", "Sine Waves")
+	if len(sum.Failed) != 0 {
+		t.Fatalf("%d tracks failed", len(sum.Failed))
+	}
+	want := []string{"01 - A440", "02 - Middle C", "03 - Octave Down"}
+	if len(sum.Saved) != len(want) {
+		t.Fatalf("saved %v, want %v", sum.Saved, want)
+	}
+	var total int64
+	for i, path := range sum.Saved {
+		if !strings.HasSuffix(filepath.Dir(path), dir) || !strings.HasPrefix(filepath.Base(path), want[i]+".") {
+			t.Errorf("track %d saved as %s, want %s", i+1, path, want[i])
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += fi.Size()
+	}
+	if sum.Bytes != total {
+		t.Errorf("summary counts %d bytes, files hold %d", sum.Bytes, total)
+	}
+}
+
+func TestAlbumNotFound(t *testing.T) {
+	mocktest.Start(t)
+
+	if _, err := Album("nope", testOptions(Skip), nil); err == nil {
+		t.Fatal("downloaded an album that does not exist")
+	}
+}
+
+func TestTrackResumesPartFile(t *testing.T) {
+	var mu sync.Mutex
+	var ranges []string
+	h := mocktest.Handler(t)
+	srv := mocktest.Serve(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/audio/") {
+			mu.Lock()
+			ranges = append(ranges, r.Header.Get("Range"))
+			mu.Unlock()
+		}
+		h.ServeHTTP(w, r)
+	}))
+	full := audio(t, srv.URL, "t1")
+	ranges = nil
+
+	// An earlier run got half the file before it was interrupted
+	opts := testOptions(Skip)
+	jobs, err := trackJobs(a440, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j := jobs[0]
+	j.Path = j.Base + ".flac"
+	half := len(full) / 2
+	if err := os.WriteFile(j.Path+partSuffix, full[:half], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := record(j); err != nil {
+		t.Fatal(err)
+	}
+
+	res := saveOne(t, a440, opts)
+	if len(ranges) != 1 || ranges[0] != "bytes="+strconv.Itoa(half)+"-" {
+		t.Errorf("audio requested with ranges %q, want only the missing half", ranges)
+	}
+	if !bytes.Equal(readFile(t, res.Path), full) {
+		t.Error("resumed file differs from the served audio")
+	}
+	if _, err := os.Stat(res.Path + partSuffix); !os.IsNotExist(err) {
+		t.Error("part file left behind")
+	}
+	if jobs, err := loadJournal("."); err != nil || len(jobs) != 0 {
+		t.Errorf("journal still holds %v (%v)", jobs, err)
+	}
+}
+
+func TestTrackRejectsWrongLength(t *testing.T) {
+	srv := mocktest.Start(t)
+
+	long := a440
+	long.Duration = models.Ptr(500)
+	_, err := Track(long, testOptions(Skip))
+	if err == nil || !strings.Contains(err.Error(), "long but the track is") {
+		t.Fatalf("got %v, want a length mismatch", err)
+	}
+	if _, err := os.Stat("A440.flac"); !os.IsNotExist(err) {
+		t.Error("moved into place despite the failed check")
+	}
+	// The whole download stays, in case the track's length is what is wrong
+	if len(readFile(t, "A440.flac"+partSuffix)) != len(audio(t, srv.URL, "t1")) {
+		t.Error("part file does not hold the whole download")
+	}
+	// Kept in the journal, failed, for a later resume to report
+	jobs, err := loadJournal(".")
+	if err != nil || len(jobs) != 1 || jobs[0].State != Failed {
+		t.Errorf("journal holds %+v (%v), want one failed job", jobs, err)
+	}
+}
+
+func TestTrackCollisionPolicies(t *testing.T) {
+	srv := mocktest.Start(t)
+	full := audio(t, srv.URL, "t1")
+	old := []byte("not audio")
+	if err := os.WriteFile("A440.flac", old, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res := saveOne(t, a440, testOptions(Skip))
+	if !res.Skipped || !bytes.Equal(readFile(t, "A440.flac"), old) {
+		t.Errorf("skip: got %+v", res)
+	}
+
+	res = saveOne(t, a440, testOptions(Suffix))
+	if res.Skipped || !strings.HasSuffix(res.Path, "A440 (1).flac") || !bytes.Equal(readFile(t, res.Path), full) {
+		t.Errorf("suffix: got %+v", res)
+	}
+	if !bytes.Equal(readFile(t, "A440.flac"), old) {
+		t.Error("suffix: existing file replaced")
+	}
+
+	res = saveOne(t, a440, testOptions(Overwrite))
+	if res.Skipped || !strings.HasSuffix(res.Path, "A440.flac") || !bytes.Equal(readFile(t, "A440.flac"), full) {
+		t.Errorf("overwrite: got %+v", res)
+	}
+}
+
+func TestTrackTagsUnlessTold(t *testing.T) {
+	mocktest.Start(t)
+
+	opts := testOptions(Overwrite)
+	opts.NoTags = false
+	res := saveOne(t, a440, opts)
+	if res.TagErr != nil {
+		t.Fatal(res.TagErr)
+	}
+	data := readFile(t, res.Path)
+	if !bytes.Contains(data, []byte("ARTIST=Mock Orchestra")) || !bytes.Contains(data, []byte("image/png")) {
+		t.Error("downloaded track has no tags or cover")
+	}
+
+	opts.NoTags = true
+	res = saveOne(t, a440, opts)
+	if bytes.Contains(readFile(t, res.Path), []byte("ARTIST=")) {
+		t.Error("tagged despite NoTags")
+	}
+}
+
+func TestTrackTranscodeProfiles(t *testing.T) {
+	mocktest.Start(t)
+
+	opts := testOptions(Skip)
+	opts.Transcode = []string{"nope"}
+	if _, err := Track(a440, opts); err == nil || !strings.Contains(err.Error(), "unknown transcode profile") {
+		t.Errorf("got %v, want an unknown profile", err)
+	}
+
+	// Original alone is the file as served
+	opts.Transcode = []string{Original}
+	res := saveOne(t, a440, opts)
+	if !strings.HasSuffix(res.Path, "A440.flac") {
+		t.Errorf("saved as %s, want A440.flac", res.Path)
+	}
+}
+
+func TestTrackByIDAlone(t *testing.T) {
+	mocktest.Start(t)
+
+	res := saveOne(t, api.Track{Id: models.Ptr("t2")}, testOptions(Skip))
+	if !strings.HasSuffix(res.Path, "t2.flac") {
+		t.Errorf("saved as %s, want t2.flac", res.Path)
+	}
+}
+
+func TestTrackResumesOnlyTheSameProfile(t *testing.T) {
+	srv := mocktest.Start(t)
+	full := audio(t, srv.URL, "t1")
+
+	// An earlier run encoding to opus left its download behind
+	opts := testOptions(Skip)
+	jobs, err := trackJobs(a440, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j := jobs[0]
+	opus, err := transcode.Lookup("opus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Profile, j.Path = &opus, j.Base+opus.Ext
+	if err := os.WriteFile(j.Path+partSuffix, []byte("not this"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := record(j); err != nil {
+		t.Fatal(err)
+	}
+
+	res := saveOne(t, a440, opts)
+	if !strings.HasSuffix(res.Path, "A440.flac") || !bytes.Equal(readFile(t, res.Path), full) {
+		t.Errorf("saved %s, want a fresh A440.flac", res.Path)
+	}
+}
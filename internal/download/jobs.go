@@ -0,0 +1,202 @@
+package download
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+	"github.com/adityadeshmukh1/dab-cli/internal/tags"
+	"github.com/adityadeshmukh1/dab-cli/internal/transcode"
+)
+
+func newJob(trackID, title, base string, opts Options) (*job, error) {
+	abs, err := filepath.Abs(base)
+	if err != nil {
+		return nil, fmt.Errorf("invalid download path: %v", err)
+	}
+	return &job{
+		TrackID:  trackID,
+		Title:    title,
+		Quality:  opts.Quality,
+		Base:     abs,
+		OnExists: opts.OnExists,
+		State:    Queued,
+		root:     opts.Root,
+	}, nil
+}
+
+// outputJobs makes a job per transcode profile. path lays the track out
+// for a profile; when it gives every profile the same path, each
+// profile gets a directory of its own under the download root.
+func outputJobs(trackID, title string, opts Options, path func(profile string) (string, error)) ([]*job, error) {
+	profiles, err := opts.outputs()
+	if err != nil {
+		return nil, err
+	}
+
+	rels := make([]string, len(profiles))
+	seen := map[string]bool{}
+	shared := false
+	for i, p := range profiles {
+		if rels[i], err = path(profileName(p)); err != nil {
+			return nil, err
+		}
+		shared = shared || seen[rels[i]]
+		seen[rels[i]] = true
+	}
+
+	jobs := make([]*job, len(profiles))
+	for i, p := range profiles {
+		rel := rels[i]
+		if shared {
+			rel = filepath.Join(sanitize(profileName(p)), rel)
+		}
+		j, err := newJob(trackID, title, filepath.Join(expandHome(opts.Root), rel), opts)
+		if err != nil {
+			return nil, err
+		}
+		j.Profile = p
+		jobs[i] = j
+	}
+	return jobs, nil
+}
+
+func profileName(p *transcode.Profile) string {
+	if p == nil {
+		return Original
+	}
+	return p.Name
+}
+
+// idJobs download a track known only by its ID to "<ID>.ext"
+func idJobs(trackID string, opts Options) ([]*job, error) {
+	return outputJobs(trackID, trackID, opts, func(string) (string, error) {
+		return sanitize(trackID), nil
+	})
+}
+
+// trackJobs lay a single track out by the path template and tag it,
+// unless all there is to go by is its ID
+func trackJobs(t api.Track, opts Options) ([]*job, error) {
+	if models.Value(t.Title) == "" {
+		return idJobs(models.Value(t.Id), opts)
+	}
+	tmpl, err := ParseTemplate(opts.Template)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := outputJobs(models.Value(t.Id), models.Value(t.Title), opts, func(profile string) (string, error) {
+		f := trackFields(t, nil, 0, opts.Quality)
+		f.Profile = profile
+		return tmpl.Path(f)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, j := range jobs {
+		j.Duration = models.Value(t.Duration)
+		j.meta = &t
+		if !opts.NoTags {
+			tg := tags.FromTrack(t, nil, 0, 0)
+			j.Tags = &tg
+			j.CoverURL = models.Value(t.AlbumCover)
+		}
+	}
+	return jobs, nil
+}
+
+// albumJobs asks /download for an album and makes a job per track and
+// transcode profile, sharing one fetched cover
+func albumJobs(albumID string, opts Options) (*api.Album, []*job, error) {
+	tmpl, err := ParseTemplate(opts.Template)
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err := albumInfo(albumID, opts.Quality)
+	if err != nil {
+		return nil, nil, err
+	}
+	tracks := models.Value(a.Tracks)
+	if len(tracks) == 0 {
+		return nil, nil, fmt.Errorf("album %s has no tracks", albumID)
+	}
+
+	// One cover for the whole album
+	coverURL := models.Value(a.Cover)
+	if coverURL == "" {
+		coverURL = models.Value(tracks[0].AlbumCover)
+	}
+
+	var jobs []*job
+	for i, t := range tracks {
+		outputs, err := outputJobs(models.Value(t.Id), models.Value(t.Title), opts, func(profile string) (string, error) {
+			f := trackFields(t, a, i+1, opts.Quality)
+			f.Profile = profile
+			return tmpl.Path(f)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, j := range outputs {
+			j.Duration = models.Value(t.Duration)
+			j.meta = &tracks[i]
+			if !opts.NoTags {
+				tg := tags.FromTrack(t, a, i+1, len(tracks))
+				j.Tags = &tg
+				j.CoverURL = coverURL
+			}
+		}
+		jobs = append(jobs, outputs...)
+	}
+	return a, jobs, nil
+}
+
+// journalJobs reloads the unfinished jobs under the download root
+func journalJobs(opts Options) ([]*job, error) {
+	journalMu.Lock()
+	journal, err := loadJournal(opts.Root)
+	journalMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*job, len(journal))
+	for i := range journal {
+		journal[i].root = opts.Root
+		jobs[i] = &journal[i]
+	}
+	return jobs, nil
+}
+
+// track is the job's track for progress reports. Jobs reloaded from the
+// journal only know the ID and title.
+func (j *job) track() api.Track {
+	if j.meta != nil {
+		return *j.meta
+	}
+	title := j.Title
+	if title == "" {
+		title = filepath.Base(j.Base)
+	}
+	return api.Track{Id: models.Ptr(j.TrackID), Title: models.Ptr(title)}
+}
+
+// profile is the job's transcode profile name, empty for the file as served
+func (j *job) profile() string {
+	if j.Profile == nil {
+		return ""
+	}
+	return j.Profile.Name
+}
+
+// sameProfile reports whether two jobs encode alike, so one's files can
+// stand in for the other's
+func sameProfile(a, b *transcode.Profile) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Name == b.Name && a.Codec == b.Codec && a.Format == b.Format && a.Ext == b.Ext &&
+		a.Bitrate == b.Bitrate && a.Quality == b.Quality && slices.Equal(a.Args, b.Args)
+}
@@ -0,0 +1,146 @@
+package download
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/tags"
+	"github.com/adityadeshmukh1/dab-cli/internal/transcode"
+)
+
+// journalFile lists the unfinished downloads under a download root
+const journalFile = ".dabcli_downloads.json"
+
+// Job states
+const (
+	Queued  = "queued"
+	Running = "running"
+	Failed  = "failed"
+	Done    = "done"
+)
+
+// job is one track download. It is journaled from start to finish so
+// an interrupted download can be resumed from its .part file.
+type job struct {
+	TrackID  string     `json:"trackId"`
+	Title    string     `json:"title,omitempty"`
+	Quality  string     `json:"quality"`
+	Base     string     `json:"base"`           // absolute target path without extension
+	Path     string     `json:"path,omitempty"` // final path, once the extension is known
+	OnExists string     `json:"onExists"`
+	Duration int        `json:"duration,omitempty"` // expected seconds, 0 if unknown
+	Tags     *tags.Tags `json:"tags,omitempty"`     // nil leaves the file untagged
+	CoverURL string     `json:"coverUrl,omitempty"`
+
+	Profile *transcode.Profile `json:"profile,omitempty"` // nil keeps the file as served
+
+	State    string `json:"state"`
+	Attempts int    `json:"attempts,omitempty"`
+	Error    string `json:"error,omitempty"`
+
+	root     string
+	meta     *api.Track
+	progress func(written, total int64)
+}
+
+const (
+	// partSuffix marks a download that has not been verified yet
+	partSuffix = ".part"
+	// encodeSuffix marks a transcode in progress
+	encodeSuffix = ".encoding"
+)
+
+var journalMu sync.Mutex
+
+func journalPath(root string) string {
+	return filepath.Join(expandHome(root), journalFile)
+}
+
+func loadJournal(root string) ([]job, error) {
+	data, err := os.ReadFile(journalPath(root))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read download journal: %v", err)
+	}
+	var jobs []job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to parse download journal: %v", err)
+	}
+	return jobs, nil
+}
+
+func saveJournal(root string, jobs []job) error {
+	if len(jobs) == 0 {
+		if err := os.Remove(journalPath(root)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove download journal: %v", err)
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal download journal: %v", err)
+	}
+	if err := os.MkdirAll(expandHome(root), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(journalPath(root), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write download journal: %v", err)
+	}
+	return nil
+}
+
+// journaled returns the earlier attempt at the same target, if any
+func journaled(root, base string) (job, bool) {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+
+	jobs, _ := loadJournal(root)
+	for _, j := range jobs {
+		if j.Base == base {
+			return j, true
+		}
+	}
+	return job{}, false
+}
+
+// record adds or updates a job in the journal
+func record(j *job) error {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+
+	jobs, err := loadJournal(j.root)
+	if err != nil {
+		return err
+	}
+	for i := range jobs {
+		if jobs[i].Base == j.Base {
+			jobs[i] = *j
+			return saveJournal(j.root, jobs)
+		}
+	}
+	return saveJournal(j.root, append(jobs, *j))
+}
+
+// forget drops a finished or abandoned job from the journal
+func forget(j *job) error {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+
+	jobs, err := loadJournal(j.root)
+	if err != nil {
+		return err
+	}
+	kept := jobs[:0]
+	for _, other := range jobs {
+		if other.Base != j.Base {
+			kept = append(kept, other)
+		}
+	}
+	return saveJournal(j.root, kept)
+}
@@ -0,0 +1,288 @@
+package download
+
+import (
+	"sync"
+	"time"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+)
+
+const (
+	// DefaultWorkers is how many downloads run at once
+	DefaultWorkers = 3
+
+	// maxJobAttempts is how often the manager runs a job before leaving
+	// it failed. Each run already resumes a broken transfer a few times.
+	maxJobAttempts = 3
+	retryBackoff   = 2 * time.Second // doubled after every failed attempt
+
+	// progressInterval limits progress events per job
+	progressInterval = 100 * time.Millisecond
+)
+
+// Job is a snapshot of one managed download
+type Job struct {
+	ID       string // the target path without extension
+	Title    string
+	Profile  string // transcode profile, empty for the file as served
+	State    string // Queued, Running, Failed or Done
+	Written  int64
+	Total    int64 // -1 if unknown
+	Attempts int
+	Err      string
+	Path     string
+	RetryAt  time.Time // when a failed job is retried; zero if it is not
+	Result   Result
+}
+
+// Event reports that a job changed. Events are dropped rather than
+// block the workers, so consumers should treat them as a hint and
+// re-read Jobs.
+type Event struct {
+	Job Job
+}
+
+// Manager runs downloads on a bounded pool of workers. Jobs are
+// journaled under the download root, so a restart picks up where the
+// last run stopped.
+type Manager struct {
+	opts Options
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	jobs   []*entry
+	closed bool
+	events chan Event
+}
+
+// entry pairs a job with its display state. The job itself belongs to
+// the worker while it runs; info is guarded by the manager's lock.
+type entry struct {
+	job      *job
+	info     Job
+	lastEmit time.Time
+}
+
+// NewManager loads the unfinished jobs under the download root and
+// starts the workers. Failed jobs stay failed until retried.
+func NewManager(opts Options, workers int) (*Manager, error) {
+	if workers < 1 {
+		workers = DefaultWorkers
+	}
+	jobs, err := journalJobs(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{opts: opts, events: make(chan Event, 64)}
+	m.cond = sync.NewCond(&m.mu)
+	for _, j := range jobs {
+		if j.State != Failed {
+			j.State = Queued
+		}
+		m.jobs = append(m.jobs, m.newEntry(j))
+	}
+	for i := 0; i < workers; i++ {
+		go m.work()
+	}
+	return m, nil
+}
+
+func (m *Manager) newEntry(j *job) *entry {
+	e := &entry{job: j}
+	e.info = Job{ID: j.Base, Title: j.Title, Profile: j.profile(), State: j.State, Total: -1, Attempts: j.Attempts, Err: j.Error, Path: j.Path}
+	j.progress = func(written, total int64) {
+		m.mu.Lock()
+		e.info.Written, e.info.Total = written, total
+		due := time.Since(e.lastEmit) >= progressInterval || written == total
+		m.mu.Unlock()
+		if due {
+			m.emit(e)
+		}
+	}
+	return e
+}
+
+// Events delivers job changes
+func (m *Manager) Events() <-chan Event {
+	return m.events
+}
+
+// Jobs returns a snapshot of every job, in the order they were added
+func (m *Manager) Jobs() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := make([]Job, len(m.jobs))
+	for i, e := range m.jobs {
+		jobs[i] = e.info
+	}
+	return jobs
+}
+
+// AddTrack queues a track whose metadata is known
+func (m *Manager) AddTrack(t api.Track) error {
+	jobs, err := trackJobs(t, m.opts)
+	if err != nil {
+		return err
+	}
+	for _, j := range jobs {
+		if err := m.add(j); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddAlbum queues every track of an album and returns the album
+func (m *Manager) AddAlbum(albumID string) (*api.Album, error) {
+	a, jobs, err := albumJobs(albumID, m.opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, j := range jobs {
+		if err := m.add(j); err != nil {
+			return a, err
+		}
+	}
+	return a, nil
+}
+
+// add queues a job unless the same target is already queued or running
+func (m *Manager) add(j *job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, e := range m.jobs {
+		if e.info.ID != j.Base {
+			continue
+		}
+		if e.info.State == Queued || e.info.State == Running {
+			return nil
+		}
+		m.jobs = append(m.jobs[:i], m.jobs[i+1:]...)
+		break
+	}
+	if err := record(j); err != nil {
+		return err
+	}
+	e := m.newEntry(j)
+	m.jobs = append(m.jobs, e)
+	m.cond.Signal()
+	go m.emit(e)
+	return nil
+}
+
+// Retry queues a failed job again, resetting its attempts
+func (m *Manager) Retry(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range m.jobs {
+		if e.info.ID == id && e.info.State == Failed {
+			e.job.Attempts, e.info.Attempts = 0, 0
+			m.requeue(e)
+		}
+	}
+}
+
+// ClearFinished forgets jobs that are done
+func (m *Manager) ClearFinished() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.jobs[:0]
+	for _, e := range m.jobs {
+		if e.info.State != Done {
+			kept = append(kept, e)
+		}
+	}
+	m.jobs = kept
+}
+
+// Close stops the workers from starting new jobs. Running transfers are
+// abandoned with the process; their .part files resume next time.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+	m.cond.Broadcast()
+}
+
+func (m *Manager) work() {
+	for {
+		e := m.next()
+		if e == nil {
+			return
+		}
+		res, err := e.job.run()
+		m.finish(e, res, err)
+	}
+}
+
+// next blocks until a job is queued and claims it, or returns nil once
+// the manager is closed
+func (m *Manager) next() *entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for {
+		if m.closed {
+			return nil
+		}
+		for _, e := range m.jobs {
+			if e.info.State == Queued {
+				e.info.State, e.info.Err, e.info.RetryAt = Running, "", time.Time{}
+				e.info.Attempts++
+				go m.emit(e)
+				return e
+			}
+		}
+		m.cond.Wait()
+	}
+}
+
+// finish records a job's outcome and schedules a retry with backoff
+func (m *Manager) finish(e *entry, res Result, err error) {
+	m.mu.Lock()
+	e.info.Result, e.info.Path = res, e.job.Path
+	if err == nil {
+		e.info.State = Done
+	} else {
+		e.info.State, e.info.Err = Failed, err.Error()
+		if e.job.Attempts < maxJobAttempts {
+			delay := retryBackoff << (e.job.Attempts - 1)
+			e.info.RetryAt = time.Now().Add(delay)
+			time.AfterFunc(delay, func() {
+				m.mu.Lock()
+				defer m.mu.Unlock()
+				if e.info.State == Failed && !e.info.RetryAt.IsZero() {
+					m.requeue(e)
+				}
+			})
+		}
+	}
+	m.mu.Unlock()
+	m.emit(e)
+}
+
+// requeue puts a failed job back in line; the caller holds the lock
+func (m *Manager) requeue(e *entry) {
+	e.job.State = Queued
+	e.info.State, e.info.RetryAt = Queued, time.Time{}
+	m.cond.Signal()
+	go m.emit(e)
+}
+
+// emit sends a job snapshot without blocking
+func (m *Manager) emit(e *entry) {
+	m.mu.Lock()
+	ev := Event{Job: e.info}
+	e.lastEmit = time.Now()
+	m.mu.Unlock()
+
+	select {
+	case m.events <- ev:
+	default:
+	}
+}
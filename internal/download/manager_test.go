@@ -0,0 +1,94 @@
+package download
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/mock/mocktest"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+)
+
+// settle waits until no job is queued or running
+func settle(t *testing.T, m *Manager) []Job {
+	t.Helper()
+	deadline := time.After(10 * time.Second)
+	for {
+		busy := false
+		jobs := m.Jobs()
+		for _, j := range jobs {
+			busy = busy || j.State == Queued || j.State == Running
+		}
+		if !busy {
+			return jobs
+		}
+		select {
+		case <-m.Events():
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			t.Fatalf("jobs still busy: %+v", jobs)
+		}
+	}
+}
+
+func TestManagerRunsAnAlbum(t *testing.T) {
+	mocktest.Start(t)
+
+	opts := testOptions(Skip)
+	opts.Template = DefaultTemplate
+	m, err := NewManager(opts, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if _, err := m.AddAlbum("al1"); err != nil {
+		t.Fatal(err)
+	}
+	// Adding a track that is already queued does not queue it twice
+	if _, err := m.AddAlbum("al1"); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs := settle(t, m)
+	if len(jobs) != 3 {
+		t.Fatalf("got %d jobs, want 3", len(jobs))
+	}
+	for _, j := range jobs {
+		if j.State != Done || j.Attempts != 1 || !strings.HasSuffix(j.Path, ".flac") {
+			t.Errorf("job %+v", j)
+		}
+	}
+	m.ClearFinished()
+	if jobs := m.Jobs(); len(jobs) != 0 {
+		t.Errorf("cleared, yet %d jobs remain", len(jobs))
+	}
+	if jobs, err := loadJournal("."); err != nil || len(jobs) != 0 {
+		t.Errorf("journal still holds %v (%v)", jobs, err)
+	}
+}
+
+func TestManagerKeepsFailedJobs(t *testing.T) {
+	mocktest.Start(t)
+
+	long := a440
+	long.Duration = models.Ptr(500)
+	if _, err := Track(long, testOptions(Skip)); err == nil {
+		t.Fatal("saved a track of the wrong length")
+	}
+
+	// A new manager picks the failed job up from the journal and leaves it
+	// failed until retried
+	m, err := NewManager(testOptions(Skip), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	jobs := m.Jobs()
+	if len(jobs) != 1 || jobs[0].State != Failed || !strings.Contains(jobs[0].Err, "long but the track is") {
+		t.Fatalf("jobs %+v, want the failed one", jobs)
+	}
+	m.Retry(jobs[0].ID)
+	if jobs := settle(t, m); jobs[0].State != Failed || jobs[0].Attempts != 1 {
+		t.Errorf("retried job %+v, want it failed again after one attempt", jobs[0])
+	}
+}
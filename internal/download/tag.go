@@ -0,0 +1,88 @@
+package download
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/client"
+	"github.com/adityadeshmukh1/dab-cli/internal/tags"
+)
+
+// maxCachedCovers bounds the covers kept for an album's other tracks
+const maxCachedCovers = 8
+
+type fetchedCover struct {
+	art *tags.Cover
+	err error
+}
+
+var (
+	coversMu sync.Mutex
+	covers   = map[string]fetchedCover{}
+)
+
+// cover fetches the album cover to embed, once per URL. Downloads go
+// ahead without one if it cannot be fetched; the error is reported as a
+// tag warning.
+func cover(url string) (*tags.Cover, error) {
+	if url == "" {
+		return nil, nil
+	}
+	coversMu.Lock()
+	defer coversMu.Unlock()
+
+	if c, ok := covers[url]; ok {
+		return c.art, c.err
+	}
+	var c fetchedCover
+	abs, err := client.ResolveURL(url)
+	if err != nil {
+		c.err = err
+	} else {
+		c.art, c.err = tags.FetchCover(abs)
+	}
+	if len(covers) >= maxCachedCovers {
+		for k := range covers {
+			delete(covers, k)
+			break
+		}
+	}
+	covers[url] = c
+	return c.art, c.err
+}
+
+// tag writes metadata into a finished download. Formats that carry no
+// tags we know how to write (e.g. WAV) are left alone.
+func tag(path string, tg tags.Tags, coverURL string) error {
+	art, coverErr := cover(coverURL)
+	tg.Cover = art
+	err := tags.Write(path, tg)
+	if errors.Is(err, tags.ErrUnsupported) {
+		return nil
+	}
+	if err == nil {
+		err = coverErr
+	}
+	return err
+}
+
+// metadata is the tags as ffmpeg metadata, for transcoded formats the
+// tag writer cannot handle
+func metadata(tg tags.Tags) map[string]string {
+	m := map[string]string{
+		"title":        tg.Title,
+		"artist":       tg.Artist,
+		"album":        tg.Album,
+		"album_artist": tg.AlbumArtist,
+		"date":         tg.Date,
+		"genre":        tg.Genre,
+	}
+	if tg.Track > 0 {
+		m["track"] = strconv.Itoa(tg.Track)
+		if tg.TrackTotal > 0 {
+			m["track"] += "/" + strconv.Itoa(tg.TrackTotal)
+		}
+	}
+	return m
+}
@@ -0,0 +1,199 @@
+package download
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+)
+
+// DefaultTemplate lays downloads out as Artist/Album/NN - Title
+const DefaultTemplate = `{{.Artist}}/{{.Album}}/{{if .Track}}{{printf "%02d" .Track}} - {{end}}{{.Title}}`
+
+// What to do when the target file already exists
+const (
+	Skip      = "skip"
+	Overwrite = "overwrite"
+	Suffix    = "suffix"
+)
+
+// Policies lists the collision policies
+var Policies = []string{Skip, Overwrite, Suffix}
+
+// Fields are the values a path template can use, e.g. {{.Artist}}.
+// Values are sanitized before the template runs, so a "/" in a title
+// never creates a directory.
+type Fields struct {
+	ID      string
+	Artist  string
+	Album   string
+	Track   int // 0 when the track is not part of an album download
+	Title   string
+	Year    string
+	Genre   string
+	Quality string // e.g. "24-96", or the requested quality code if unknown
+	Profile string // transcode profile, "original" for the file as served
+}
+
+// trackFields fills the template fields for a track; a is the album it
+// is downloaded as part of, if any
+func trackFields(t api.Track, a *api.Album, number int, quality string) Fields {
+	f := Fields{
+		ID:      models.Value(t.Id),
+		Artist:  models.Value(t.Artist),
+		Album:   models.Value(t.AlbumTitle),
+		Track:   number,
+		Title:   models.Value(t.Title),
+		Year:    year(models.Value(t.ReleaseDate)),
+		Genre:   models.Value(t.Genre),
+		Quality: qualityTag(t.AudioQuality, quality),
+	}
+	if a != nil {
+		// Keep an album together even when tracks credit guests
+		f.Artist = models.Value(a.Artist)
+		f.Album = models.Value(a.Title)
+		if f.Year == "" {
+			f.Year = year(models.Value(a.ReleaseDate))
+		}
+		if f.Genre == "" {
+			f.Genre = models.Value(a.Genre)
+		}
+		if t.AudioQuality == nil {
+			f.Quality = qualityTag(a.AudioQuality, quality)
+		}
+	}
+	return f
+}
+
+func year(date string) string {
+	if len(date) >= 4 {
+		return date[:4]
+	}
+	return date
+}
+
+// qualityTag shortens an AudioQuality to "bits-kHz"
+func qualityTag(q *api.AudioQuality, fallback string) string {
+	if q == nil || q.MaximumBitDepth == nil || q.MaximumSamplingRate == nil {
+		return fallback
+	}
+	return fmt.Sprintf("%d-%s", *q.MaximumBitDepth, strconv.FormatFloat(float64(*q.MaximumSamplingRate), 'f', -1, 32))
+}
+
+// Template turns Fields into a relative path without extension
+type Template struct {
+	tmpl *template.Template
+}
+
+// ParseTemplate checks a path template. An empty string is the default.
+func ParseTemplate(s string) (*Template, error) {
+	if s == "" {
+		s = DefaultTemplate
+	}
+	tmpl, err := template.New("path").Option("missingkey=error").Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path template: %v", err)
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// Path runs the template and cleans every element of the result
+func (t *Template) Path(f Fields) (string, error) {
+	for _, v := range []*string{&f.ID, &f.Artist, &f.Album, &f.Title, &f.Year, &f.Genre, &f.Quality, &f.Profile} {
+		*v = strings.NewReplacer("/", "-", "\\", "-").Replace(*v)
+	}
+
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, f); err != nil {
+		return "", fmt.Errorf("path template: %v", err)
+	}
+
+	parts := strings.Split(filepath.ToSlash(b.String()), "/")
+	for i, p := range parts {
+		parts[i] = sanitize(p)
+	}
+	return filepath.Join(parts...), nil
+}
+
+// reserved are device names Windows refuses as file names
+var reserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// maxNameBytes leaves room for a suffix and extension under the usual
+// 255-byte file name limit
+const maxNameBytes = 200
+
+// sanitize makes a name safe to use as a single path element on any
+// common filesystem
+func sanitize(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '-'
+		}
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.Join(strings.Fields(name), " ")
+
+	for len(name) > maxNameBytes {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	name = strings.Trim(name, " .")
+
+	if name == "" {
+		return "Unknown"
+	}
+	if reserved[strings.ToUpper(strings.SplitN(name, ".", 2)[0])] {
+		name = "_" + name
+	}
+	return name
+}
+
+// resolve applies the collision policy to a target path. It returns
+// the path to write, or skip=true if the existing file should be kept.
+func resolve(path, policy string) (string, bool, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return path, false, nil
+	}
+	switch policy {
+	case Skip, "":
+		return path, true, nil
+	case Overwrite:
+		return path, false, nil
+	case Suffix:
+		ext := filepath.Ext(path)
+		base := strings.TrimSuffix(path, ext)
+		for n := 1; ; n++ {
+			p := fmt.Sprintf("%s (%d)%s", base, n, ext)
+			if _, err := os.Stat(p); os.IsNotExist(err) {
+				return p, false, nil
+			}
+		}
+	}
+	return "", false, fmt.Errorf("unknown collision policy %q (want %s)", policy, strings.Join(Policies, ", "))
+}
+
+// expandHome turns a leading ~ into the user's home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
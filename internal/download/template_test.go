@@ -0,0 +1,79 @@
+package download
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"Plain Title", "Plain Title"},
+		{"AC/DC", "AC-DC"},
+		{`What? "Why": <Not>*|\`, "What- -Why-- -Not----"},
+		{"tab\there\x00\x7f", "tabhere"},
+		{"  lots   of\n space  ", "lots of space"},
+		{"trailing dots...", "trailing dots"},
+		{"...", "Unknown"},
+		{"", "Unknown"},
+		{"CON", "_CON"},
+		{"nul.txt", "_nul.txt"},
+		{"Console", "Console"},
+	} {
+		if got := sanitize(tc.in); got != tc.want {
+			t.Errorf("sanitize(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestSanitizeTruncatesOnRuneBoundary(t *testing.T) {
+	got := sanitize(strings.Repeat("é", 150)) // 300 bytes
+	if len(got) > maxNameBytes || !strings.HasPrefix(strings.Repeat("é", 150), got) {
+		t.Errorf("got %d bytes, %q", len(got), got)
+	}
+}
+
+func TestTemplatePath(t *testing.T) {
+	f := Fields{
+		ID:      "t1",
+		Artist:  "AC/DC",
+		Album:   "Back: In Black",
+		Track:   3,
+		Title:   "../../etc/passwd",
+		Year:    "1980",
+		Quality: "24-96",
+		Profile: Original,
+	}
+	for _, tc := range []struct{ tmpl, want string }{
+		{"", "AC-DC/Back- In Black/03 - ..-..-etc-passwd"},
+		{"{{.Year}} {{.Album}}/{{.Title}} [{{.Quality}}]", "1980 Back- In Black/-..-etc-passwd [24-96]"},
+		{"{{.Profile}}/{{.ID}}", "original/t1"},
+		// Separators written by the template itself still make directories
+		{"{{.Artist}}//{{.Title}}", "AC-DC/Unknown/-..-etc-passwd"},
+	} {
+		tmpl, err := ParseTemplate(tc.tmpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := tmpl.Path(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != filepath.FromSlash(tc.want) {
+			t.Errorf("%q: got %q, want %q", tc.tmpl, got, tc.want)
+		}
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	if _, err := ParseTemplate("{{.Title"); err == nil {
+		t.Error("unterminated action parsed")
+	}
+	tmpl, err := ParseTemplate("{{.Nope}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tmpl.Path(Fields{}); err == nil {
+		t.Error("unknown field accepted")
+	}
+}
@@ -0,0 +1,165 @@
+package download
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+)
+
+// checkDuration compares a downloaded file's length with the track's.
+// Formats we cannot measure pass unchecked.
+func checkDuration(path string, want int) error {
+	if want <= 0 {
+		return nil
+	}
+	got, ok := audioDuration(path)
+	if !ok {
+		return nil
+	}
+	// Allow for encoder padding and rounding in the API's durations
+	tolerance := math.Max(2, 0.02*float64(want))
+	if math.Abs(got-float64(want)) > tolerance {
+		return fmt.Errorf("file is %s long but the track is %s", models.Duration(int(got)), models.Duration(want))
+	}
+	return nil
+}
+
+// audioDuration measures FLAC, WAV and MP3 files in seconds
+func audioDuration(path string) (float64, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	head, err := r.Peek(12)
+	if err != nil {
+		return 0, false
+	}
+	switch {
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return flacDuration(r)
+	case bytes.HasPrefix(head, []byte("RIFF")) && string(head[8:12]) == "WAVE":
+		return wavDuration(r)
+	}
+	return mp3Duration(r)
+}
+
+// flacDuration reads the sample count from STREAMINFO, always the first block
+func flacDuration(r io.Reader) (float64, bool) {
+	var head [4 + 4 + 18]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, false
+	}
+	packed := binary.BigEndian.Uint64(head[18:26])
+	rate := packed >> 44
+	samples := packed & (1<<36 - 1)
+	if rate == 0 || samples == 0 {
+		return 0, false
+	}
+	return float64(samples) / float64(rate), true
+}
+
+// wavDuration divides the data chunk by the byte rate from the fmt chunk
+func wavDuration(r io.Reader) (float64, bool) {
+	if _, err := io.CopyN(io.Discard, r, 12); err != nil {
+		return 0, false
+	}
+	var byteRate uint32
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return 0, false
+		}
+		size := binary.LittleEndian.Uint32(chunk[4:])
+		switch string(chunk[:4]) {
+		case "fmt ":
+			var fmtChunk [16]byte
+			if size < 16 {
+				return 0, false
+			}
+			if _, err := io.ReadFull(r, fmtChunk[:]); err != nil {
+				return 0, false
+			}
+			byteRate = binary.LittleEndian.Uint32(fmtChunk[8:12])
+			size -= 16
+		case "data":
+			if byteRate == 0 {
+				return 0, false
+			}
+			return float64(size) / float64(byteRate), true
+		}
+		if _, err := io.CopyN(io.Discard, r, int64(size+size%2)); err != nil {
+			return 0, false
+		}
+	}
+}
+
+// Layer III bitrates in kbit/s for MPEG-1 and MPEG-2/2.5
+var mp3Bitrates = [2][15]int{
+	{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+}
+
+// Sample rates for MPEG-1, MPEG-2 and MPEG-2.5
+var mp3SampleRates = [3][3]int{
+	{44100, 48000, 32000},
+	{22050, 24000, 16000},
+	{11025, 12000, 8000},
+}
+
+// mp3Duration skips any ID3v2 tag and walks the Layer III frames. Only
+// a walk that reaches the end, or an ID3v1 tag right before it, measures
+// the whole file: anything else it stops at, such as an APEv2 or Lyrics3
+// tag or trailing junk, may hide more audio, so the length is unknown.
+func mp3Duration(r *bufio.Reader) (float64, bool) {
+	if head, err := r.Peek(10); err == nil && string(head[:3]) == "ID3" {
+		size := int(head[6])<<21 | int(head[7])<<14 | int(head[8])<<7 | int(head[9])
+		if _, err := r.Discard(10 + size); err != nil {
+			return 0, false
+		}
+	}
+
+	var seconds float64
+	frames := 0
+	for {
+		head, err := r.Peek(4)
+		if err != nil || head[0] != 0xff || head[1]&0xe0 != 0xe0 {
+			break
+		}
+		version := head[1] >> 3 & 3 // 0 = 2.5, 2 = 2, 3 = 1
+		layer := head[1] >> 1 & 3   // 1 = Layer III
+		bitrate := int(head[2] >> 4)
+		rate := int(head[2] >> 2 & 3)
+		padding := int(head[2] >> 1 & 1)
+		if version == 1 || layer != 1 || bitrate == 0 || bitrate == 15 || rate == 3 {
+			break
+		}
+
+		v1 := version == 3
+		table, rates, samples, coeff := 1, 1, 576, 72
+		if v1 {
+			table, rates, samples, coeff = 0, 0, 1152, 144
+		} else if version == 0 {
+			rates = 2
+		}
+		sampleRate := mp3SampleRates[rates][rate]
+		length := coeff*mp3Bitrates[table][bitrate]*1000/sampleRate + padding
+
+		seconds += float64(samples) / float64(sampleRate)
+		frames++
+		if _, err := r.Discard(length); err != nil {
+			break
+		}
+	}
+	rest, _ := r.Peek(129)
+	atEnd := len(rest) == 0 || len(rest) == 128 && bytes.HasPrefix(rest, []byte("TAG"))
+	return seconds, frames > 0 && atEnd
+}
@@ -0,0 +1,40 @@
+package download
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/mock/mocktest"
+)
+
+func TestMP3DurationNeedsTheWholeWalk(t *testing.T) {
+	srv := mocktest.Start(t)
+	mp3 := audioAt(t, srv.URL, "t1", "5")
+
+	measure := func(data []byte) (float64, bool) {
+		return mp3Duration(bufio.NewReader(bytes.NewReader(data)))
+	}
+	full, ok := measure(mp3)
+	if !ok || math.Abs(full-95) > 2 {
+		t.Fatalf("got %.1fs (ok %v), want about 95s", full, ok)
+	}
+
+	id3v1 := append([]byte("TAG"), make([]byte, 125)...)
+	if got, ok := measure(append(mp3, id3v1...)); !ok || got != full {
+		t.Errorf("with an ID3v1 tag: %.1fs (ok %v)", got, ok)
+	}
+	for name, tail := range map[string][]byte{
+		"APEv2":  append([]byte("APETAGEX"), make([]byte, 24)...),
+		"Lyrics": []byte("LYRICSBEGIN" + strings.Repeat("la", 20) + "LYRICS200"),
+		"junk":   make([]byte, 300),
+	} {
+		// Audio after the tag would go unmeasured
+		data := append(append(append([]byte{}, mp3...), tail...), mp3...)
+		if _, ok := measure(data); ok {
+			t.Errorf("%s: measured a file the walk did not reach the end of", name)
+		}
+	}
+}
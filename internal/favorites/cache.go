@@ -0,0 +1,144 @@
+package favorites
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/config"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+)
+
+// CacheFile is where the favorites are kept between sessions, so hearts
+// show before the server answers. Each server has its own.
+func CacheFile() string {
+	return config.ServerFile(".dabcli_favorites.json")
+}
+
+// Cache is the local copy of the favorite tracks. Every change is saved
+// to disk.
+type Cache struct {
+	mu     sync.Mutex
+	path   string
+	tracks []api.Track
+}
+
+// LoadCache reads the favorites cached in CacheFile
+func LoadCache() (*Cache, error) {
+	return OpenCache(CacheFile())
+}
+
+// OpenCache reads favorites cached at path; a missing file is an empty cache
+func OpenCache(path string) (*Cache, error) {
+	c := &Cache{path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read favorites: %v", err)
+	}
+	if err := json.Unmarshal(data, &c.tracks); err != nil {
+		return nil, fmt.Errorf("failed to parse favorites %s: %v", path, err)
+	}
+	return c, nil
+}
+
+// ResetCache starts an empty cache in CacheFile for when LoadCache
+// failed. The unreadable file is first moved aside, with ".bad" added,
+// so the next save does not destroy it; the cache is usable even if
+// that fails.
+func ResetCache() (*Cache, error) {
+	c := &Cache{path: CacheFile()}
+	if err := os.Rename(c.path, c.path+".bad"); err != nil && !os.IsNotExist(err) {
+		return c, fmt.Errorf("failed to move the favorites cache aside: %v", err)
+	}
+	return c, nil
+}
+
+// save writes the cache; the caller holds the lock
+func (c *Cache) save() error {
+	data, err := json.MarshalIndent(c.tracks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal favorites: %v", err)
+	}
+	if err := os.WriteFile(c.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to save favorites: %v", err)
+	}
+	return nil
+}
+
+// Tracks returns a copy of the cached favorites
+func (c *Cache) Tracks() []api.Track {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]api.Track(nil), c.tracks...)
+}
+
+// Has reports whether the track with id is a favorite
+func (c *Cache) Has(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.index(id) >= 0
+}
+
+// Get returns the cached favorite with id, if there is one
+func (c *Cache) Get(id string) (api.Track, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if i := c.index(id); i >= 0 {
+		return c.tracks[i], true
+	}
+	return api.Track{}, false
+}
+
+func (c *Cache) index(id string) int {
+	for i, t := range c.tracks {
+		if models.Value(t.Id) == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Set replaces the cache with the favorites the server listed
+func (c *Cache) Set(tracks []api.Track) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tracks = append([]api.Track(nil), tracks...)
+	return c.save()
+}
+
+// Put caches t as a favorite
+func (c *Cache) Put(t api.Track) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.index(models.Value(t.Id)) >= 0 {
+		return nil
+	}
+	c.tracks = append(c.tracks, t)
+	return c.save()
+}
+
+// Drop uncaches the favorite with id
+func (c *Cache) Drop(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.index(id)
+	if i < 0 {
+		return nil
+	}
+	c.tracks = append(c.tracks[:i], c.tracks[i+1:]...)
+	return c.save()
+}
+
+// Refresh lists the favorites on the server and caches them
+func (c *Cache) Refresh() ([]api.Track, error) {
+	tracks, err := List()
+	if err != nil {
+		return nil, err
+	}
+	return tracks, c.Set(tracks)
+}
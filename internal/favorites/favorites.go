@@ -0,0 +1,64 @@
+package favorites
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/client"
+)
+
+// List returns the logged-in user's favorite tracks
+func List() ([]api.Track, error) {
+	c, err := client.New()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.GetFavoritesWithResponse(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("favorites request failed: %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, client.ResponseError("favorites", resp.StatusCode(), resp.Body)
+	}
+	if resp.JSON200 == nil || resp.JSON200.Favorites == nil {
+		return nil, nil
+	}
+	return *resp.JSON200.Favorites, nil
+}
+
+// Add marks a track as a favorite
+func Add(track api.Track) error {
+	c, err := client.New()
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.PostFavoritesWithResponse(context.Background(), api.PostFavoritesJSONRequestBody{Track: track})
+	if err != nil {
+		return fmt.Errorf("favorite request failed: %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK && resp.StatusCode() != http.StatusCreated {
+		return client.ResponseError("add favorite", resp.StatusCode(), resp.Body)
+	}
+	return nil
+}
+
+// Remove unmarks a favorite track
+func Remove(trackID string) error {
+	c, err := client.New()
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.DeleteFavoritesWithResponse(context.Background(), &api.DeleteFavoritesParams{TrackId: trackID})
+	if err != nil {
+		return fmt.Errorf("unfavorite request failed: %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return client.ResponseError("remove favorite", resp.StatusCode(), resp.Body)
+	}
+	return nil
+}
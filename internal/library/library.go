@@ -0,0 +1,205 @@
+package library
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/client"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+	"github.com/adityadeshmukh1/dab-cli/internal/pager"
+)
+
+// Library is a library's metadata together with one page of its tracks
+type Library struct {
+	api.Library
+	Tracks     []api.Track    `json:"tracks"`
+	Pagination api.Pagination `json:"pagination"`
+}
+
+// List returns the logged-in user's libraries
+func List() ([]api.Library, error) {
+	c, err := client.New()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.GetLibrariesWithResponse(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("libraries request failed: %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, client.ResponseError("libraries", resp.StatusCode(), resp.Body)
+	}
+	if resp.JSON200 == nil || resp.JSON200.Libraries == nil {
+		return nil, nil
+	}
+	return *resp.JSON200.Libraries, nil
+}
+
+// Get fetches a library and one page of its tracks. Zero page or limit
+// leaves the choice to the server.
+func Get(id string, page, limit int) (*Library, error) {
+	return GetContext(context.Background(), id, page, limit)
+}
+
+// GetContext is Get, given up when ctx is cancelled
+func GetContext(ctx context.Context, id string, page, limit int) (*Library, error) {
+	c, err := client.New()
+	if err != nil {
+		return nil, err
+	}
+
+	params := &api.GetLibrariesIdParams{}
+	if page > 0 {
+		params.Page = &page
+	}
+	if limit > 0 {
+		params.Limit = &limit
+	}
+	resp, err := c.GetLibrariesIdWithResponse(ctx, id, params)
+	if err != nil {
+		return nil, fmt.Errorf("library request failed: %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, client.ResponseError("library", resp.StatusCode(), resp.Body)
+	}
+	if resp.JSON200 == nil || resp.JSON200.Library == nil {
+		return nil, fmt.Errorf("library %s not found", id)
+	}
+
+	l := resp.JSON200.Library
+	lib := &Library{
+		Library: api.Library{
+			Id:          l.Id,
+			Name:        l.Name,
+			Description: l.Description,
+			IsPublic:    l.IsPublic,
+			CreatedAt:   l.CreatedAt,
+			TrackCount:  l.TrackCount,
+		},
+		Tracks:     models.Value(l.Tracks),
+		Pagination: models.Value(l.Pagination),
+	}
+	return lib, nil
+}
+
+// Page is the page of tracks lib holds, to start a pager with
+func (l *Library) Page() pager.Page[api.Track] {
+	return pager.Page[api.Track]{
+		Number:     max(models.Value(l.Pagination.Page), 1),
+		Items:      l.Tracks,
+		Pagination: l.Pagination,
+	}
+}
+
+// Tracks pages through the tracks of library id
+func Tracks(id string) pager.Fetch[api.Track] {
+	return func(ctx context.Context, page, limit int) (pager.Page[api.Track], error) {
+		lib, err := GetContext(ctx, id, page, limit)
+		if err != nil {
+			return pager.Page[api.Track]{}, err
+		}
+		pg := lib.Page()
+		pg.Number = page
+		return pg, nil
+	}
+}
+
+// All fetches every track of library id, a page at a time
+func All(ctx context.Context, id string) ([]api.Track, error) {
+	return pager.New(Tracks(id), 0).All(ctx)
+}
+
+// Create makes a library for the logged-in user
+func Create(name, description string, public bool) (api.Library, error) {
+	c, err := client.New()
+	if err != nil {
+		return api.Library{}, err
+	}
+
+	body := api.PostLibrariesJSONRequestBody{Name: name, IsPublic: &public}
+	if description != "" {
+		body.Description = &description
+	}
+	resp, err := c.PostLibrariesWithResponse(context.Background(), body)
+	if err != nil {
+		return api.Library{}, fmt.Errorf("create library request failed: %v", err)
+	}
+	if resp.StatusCode() != http.StatusCreated {
+		return api.Library{}, client.ResponseError("create library", resp.StatusCode(), resp.Body)
+	}
+	if resp.JSON201 == nil || resp.JSON201.Library == nil {
+		return api.Library{}, fmt.Errorf("create library: no library in the response")
+	}
+	return *resp.JSON201.Library, nil
+}
+
+// Update changes the fields of library id that are set in changes
+func Update(id string, changes api.PatchLibrariesIdJSONRequestBody) error {
+	c, err := client.New()
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.PatchLibrariesIdWithResponse(context.Background(), id, changes)
+	if err != nil {
+		return fmt.Errorf("update library request failed: %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return client.ResponseError("update library", resp.StatusCode(), resp.Body)
+	}
+	return nil
+}
+
+// Delete deletes library id
+func Delete(id string) error {
+	c, err := client.New()
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.DeleteLibrariesIdWithResponse(context.Background(), id)
+	if err != nil {
+		return fmt.Errorf("delete library request failed: %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK && resp.StatusCode() != http.StatusNoContent {
+		return client.ResponseError("delete library", resp.StatusCode(), resp.Body)
+	}
+	return nil
+}
+
+// AddTrack adds a track to library id; one already there is left alone
+func AddTrack(id string, track api.Track) error {
+	c, err := client.New()
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.PostLibrariesIdTracksWithResponse(context.Background(), id, api.PostLibrariesIdTracksJSONRequestBody{Track: track})
+	if err != nil {
+		return fmt.Errorf("add to library request failed: %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK && resp.StatusCode() != http.StatusCreated {
+		return client.ResponseError("add to library", resp.StatusCode(), resp.Body)
+	}
+	return nil
+}
+
+// RemoveTrack takes a track out of library id
+func RemoveTrack(id, trackID string) error {
+	c, err := client.New()
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.DeleteLibrariesIdTracksTrackIdWithResponse(context.Background(), id, trackID)
+	if err != nil {
+		return fmt.Errorf("remove from library request failed: %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK && resp.StatusCode() != http.StatusNoContent {
+		return client.ResponseError("remove from library", resp.StatusCode(), resp.Body)
+	}
+	return nil
+}
@@ -1,60 +1,84 @@
 package login
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
-)
 
-type LoginPayload struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-}
+	openapi_types "github.com/oapi-codegen/runtime/types"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/client"
+)
 
 func Login(email, password string) error {
-	payload := LoginPayload{Email: email, Password: password}
-	data, err := json.Marshal(payload)
+	c, err := client.New()
 	if err != nil {
-		return fmt.Errorf("failed to marshal login payload: %v", err)
+		return err
 	}
 
-	req, err := http.NewRequest("POST", "https://dab.yeet.su/api/auth/login", bytes.NewBuffer(data))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
+	body := api.PostAuthLoginJSONRequestBody{
+		Email:    openapi_types.Email(email),
+		Password: password,
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.PostAuthLoginWithResponse(context.Background(), body)
 	if err != nil {
 		return fmt.Errorf("login request failed: %v", err)
 	}
-
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("login failed: %s", string(body))
+	if resp.StatusCode() != http.StatusOK {
+		return client.ResponseError("login", resp.StatusCode(), resp.Body)
 	}
-	// Grab that session cookie!
-	for _, cookie := range resp.Cookies() {
-		if cookie.Name == "session" {
-			f, err := os.Create(".session")
-			if err != nil {
-				return fmt.Errorf("failed to write session: %v", err)
-			}
-			defer f.Close()
 
-			if _, err := f.WriteString(cookie.Value); err != nil {
-				return fmt.Errorf("failed to write session value: %v", err)
+	// Grab that session cookie!
+	for _, cookie := range resp.HTTPResponse.Cookies() {
+		if cookie.Name == client.SessionCookie {
+			if err := client.SaveSession(cookie.Value); err != nil {
+				return err
 			}
-
-			fmt.Println("Login Successful!")
 			return nil
 		}
 	}
 	return fmt.Errorf("no session cookie found")
 }
+
+// Logout forgets the session locally and ends it on the server. The local
+// session goes whatever the server says; remote reports a server that could
+// not be told, and err a session file that could not be removed.
+func Logout() (remote error, err error) {
+	c, err := client.New()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, rerr := c.PostAuthLogoutWithResponse(context.Background())
+	if err := client.ClearSession(); err != nil {
+		return nil, err
+	}
+	if rerr != nil {
+		return fmt.Errorf("logout request failed: %v", rerr), nil
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return client.ResponseError("logout", resp.StatusCode(), resp.Body), nil
+	}
+	return nil, nil
+}
+
+// WhoAmI returns the logged-in user, or nil when the session is missing or expired
+func WhoAmI() (*api.User, error) {
+	c, err := client.New()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.GetAuthMeWithResponse(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("whoami request failed: %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, client.ResponseError("whoami", resp.StatusCode(), resp.Body)
+	}
+	if resp.JSON200 == nil {
+		return nil, nil
+	}
+	return resp.JSON200.User, nil
+}
@@ -0,0 +1,44 @@
+package lyrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/client"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+)
+
+// Lyrics is the text of a song and whether it carries timestamps
+type Lyrics struct {
+	Artist string `json:"artist"`
+	Title  string `json:"title"`
+	Text   string `json:"lyrics"`
+	Synced bool   `json:"synced"`
+}
+
+// Get looks up lyrics by artist name and song title
+func Get(artist, title string) (*Lyrics, error) {
+	c, err := client.New()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.GetLyricsWithResponse(context.Background(), &api.GetLyricsParams{Artist: artist, Title: title})
+	if err != nil {
+		return nil, fmt.Errorf("lyrics request failed: %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, client.ResponseError("lyrics", resp.StatusCode(), resp.Body)
+	}
+	if resp.JSON200 == nil {
+		return nil, fmt.Errorf("no lyrics returned")
+	}
+	return &Lyrics{
+		Artist: artist,
+		Title:  title,
+		Text:   models.Value(resp.JSON200.Lyrics),
+		Synced: !models.Value(resp.JSON200.Unsynced),
+	}, nil
+}
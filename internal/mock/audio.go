@@ -0,0 +1,184 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"unicode/utf8"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+)
+
+const (
+	sampleRate      = 8000
+	defaultDuration = 30 // seconds, for tracks without a duration
+)
+
+// toneWAV renders a track as a mono 8-bit sine wave lasting the track's
+// duration. The pitch is derived from the track ID so every track sounds
+// different and the output is stable across runs.
+func toneWAV(t api.Track) *bytes.Reader {
+	seconds := models.Value(t.Duration)
+	if seconds <= 0 {
+		seconds = defaultDuration
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(models.Value(t.Id)))
+	freq := 220 + float64(h.Sum32()%440)
+
+	n := seconds * sampleRate
+	var buf bytes.Buffer
+	buf.Grow(44 + n)
+
+	// RIFF/WAVE header for 8-bit unsigned PCM
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+n))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))         // fmt chunk size
+	binary.Write(&buf, binary.LittleEndian, uint16(1))          // PCM
+	binary.Write(&buf, binary.LittleEndian, uint16(1))          // channels
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate)) // sample rate
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate)) // byte rate
+	binary.Write(&buf, binary.LittleEndian, uint16(1))          // block align
+	binary.Write(&buf, binary.LittleEndian, uint16(8))          // bits per sample
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(n))
+
+	for i := 0; i < n; i++ {
+		v := math.Sin(2 * math.Pi * freq * float64(i) / sampleRate)
+		buf.WriteByte(byte(128 + 60*v))
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+// tone returns the track's samples as unsigned 8-bit PCM, the body of toneWAV
+func tone(t api.Track) []byte {
+	wav, _ := io.ReadAll(toneWAV(t))
+	return wav[44:]
+}
+
+// FLAC frames hold this many samples; the last one may be shorter
+const flacBlockSize = 4096
+
+// toneFLAC wraps the same tone in a FLAC stream of verbatim (uncompressed)
+// frames, so downloads at lossless qualities can be tagged and probed
+// like the real thing.
+func toneFLAC(t api.Track) *bytes.Reader {
+	pcm := tone(t)
+
+	var buf bytes.Buffer
+	buf.WriteString("fLaC")
+
+	// STREAMINFO, the only (and so last) metadata block
+	var info bytes.Buffer
+	binary.Write(&info, binary.BigEndian, uint16(flacBlockSize)) // min block size
+	binary.Write(&info, binary.BigEndian, uint16(flacBlockSize)) // max block size
+	info.Write([]byte{0, 0, 0, 0, 0, 0})                         // frame sizes unknown
+	// sample rate (20 bits), channels-1 (3), bits per sample-1 (5), total samples (36)
+	packed := uint64(sampleRate)<<44 | 0<<41 | 7<<36 | uint64(len(pcm))
+	binary.Write(&info, binary.BigEndian, packed)
+	info.Write(make([]byte, 16)) // MD5 unknown
+	buf.Write([]byte{0x80, 0, 0, byte(info.Len())})
+	buf.Write(info.Bytes())
+
+	for frame, off := 0, 0; off < len(pcm); frame, off = frame+1, off+flacBlockSize {
+		block := pcm[off:min(off+flacBlockSize, len(pcm))]
+
+		var f bytes.Buffer
+		f.Write([]byte{0xff, 0xf8})
+		if len(block) == flacBlockSize {
+			f.WriteByte(0xc4) // 4096 samples, 8 kHz
+		} else {
+			f.WriteByte(0x74) // 16-bit block size at end of header, 8 kHz
+		}
+		f.WriteByte(0x02) // mono, 8 bits per sample
+		f.Write(utf8.AppendRune(nil, rune(frame)))
+		if len(block) != flacBlockSize {
+			binary.Write(&f, binary.BigEndian, uint16(len(block)-1))
+		}
+		f.WriteByte(crc8(f.Bytes()))
+
+		f.WriteByte(0x02) // verbatim subframe
+		for _, s := range block {
+			f.WriteByte(s - 128) // signed samples
+		}
+		binary.Write(&f, binary.BigEndian, crc16(f.Bytes()))
+		buf.Write(f.Bytes())
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func crc8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x8005
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// MPEG-1 Layer III, 128 kbit/s, 44.1 kHz, mono. A frame whose side info
+// and main data are all zero decodes as silence.
+const (
+	mp3FrameHeader  = 0xfffb90c4
+	mp3FrameBytes   = 417
+	mp3FrameSamples = 1152
+	mp3SampleRate   = 44100
+)
+
+// silenceMP3 is a silent MP3 lasting the track's duration. It stands in
+// for the lossy download quality; encoding the tone is not worth it here.
+func silenceMP3(t api.Track) *bytes.Reader {
+	seconds := models.Value(t.Duration)
+	if seconds <= 0 {
+		seconds = defaultDuration
+	}
+	frames := seconds * mp3SampleRate / mp3FrameSamples
+
+	frame := make([]byte, mp3FrameBytes)
+	binary.BigEndian.PutUint32(frame, mp3FrameHeader)
+	return bytes.NewReader(bytes.Repeat(frame, frames))
+}
+
+// coverPNG is a flat-colour album cover, its colour derived from the name
+func coverPNG(name string) []byte {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	sum := h.Sum32()
+	c := color.RGBA{R: byte(sum), G: byte(sum >> 8), B: byte(sum >> 16), A: 0xff}
+
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: c}, image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
@@ -0,0 +1,104 @@
+package mock_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/client"
+	"github.com/adityadeshmukh1/dab-cli/internal/login"
+	"github.com/adityadeshmukh1/dab-cli/internal/mock"
+	"github.com/adityadeshmukh1/dab-cli/internal/mock/mocktest"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+	"github.com/adityadeshmukh1/dab-cli/internal/search"
+)
+
+func TestLogin(t *testing.T) {
+	mocktest.Start(t)
+
+	if err := login.Login("demo@example.com", "wrong"); err == nil {
+		t.Fatal("logged in with the wrong password")
+	}
+	if _, err := client.LoadSession(); err == nil {
+		t.Fatal("a failed login saved a session")
+	}
+	mocktest.Login(t)
+	if _, err := client.LoadSession(); err != nil {
+		t.Fatalf("no session after logging in: %v", err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	mocktest.Start(t)
+
+	tracks, err := search.Search("sine waves")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, tr := range tracks {
+		titles = append(titles, models.Value(tr.Title))
+	}
+	if got := strings.Join(titles, ", "); got != "A440, Middle C, Octave Down" {
+		t.Errorf("found %s", got)
+	}
+}
+
+func TestSearchLimit(t *testing.T) {
+	srv := mocktest.Start(t)
+
+	for limit, want := range map[string]int{"1": 200, "50": 200, "0": 400, "51": 400, "-1": 400} {
+		resp, err := http.Get(srv.URL + mock.BasePath + "/search?q=mock&limit=" + limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("limit %s: status %d, want %d", limit, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestLoadSeedDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"tracks.json": `[{"id": "x1", "title": "Only Track", "artist": "Someone"}]`,
+		"users.yaml":  "- {id: 7, username: sam, email: sam@example.com, password: pw}\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	seed, err := mock.LoadSeedDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seed.Tracks) != 1 || models.Value(seed.Tracks[0].Title) != "Only Track" {
+		t.Errorf("tracks %+v", seed.Tracks)
+	}
+	if len(seed.Users) != 1 || seed.Users[0].Email != "sam@example.com" || len(seed.Albums) != 0 {
+		t.Errorf("users %+v, albums %+v", seed.Users, seed.Albums)
+	}
+
+	mocktest.Serve(t, mock.New(seed).Handler())
+	if err := login.Login("sam@example.com", "pw"); err != nil {
+		t.Fatal(err)
+	}
+	if tracks, err := search.Search("only"); err != nil || len(tracks) != 1 {
+		t.Errorf("search over the seed: %d tracks, %v", len(tracks), err)
+	}
+}
+
+func TestLoadSeedDirReportsUnreadableFixtures(t *testing.T) {
+	dir := t.TempDir()
+	// A directory where the fixture should be cannot be read as one
+	if err := os.Mkdir(filepath.Join(dir, "tracks.json"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mock.LoadSeedDir(dir); err == nil || !strings.Contains(err.Error(), "tracks.json") {
+		t.Errorf("got %v, want tracks.json reported", err)
+	}
+}
@@ -0,0 +1,56 @@
+// Package mocktest points the API client at a mock server for tests
+package mocktest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/config"
+	"github.com/adityadeshmukh1/dab-cli/internal/login"
+	"github.com/adityadeshmukh1/dab-cli/internal/mock"
+)
+
+// Handler is the mock API over the built-in fixtures
+func Handler(t testing.TB) http.Handler {
+	t.Helper()
+	seed, err := mock.DefaultSeed()
+	if err != nil {
+		t.Fatalf("load seed: %v", err)
+	}
+	return mock.New(seed).Handler()
+}
+
+// Start serves the mock API over the built-in fixtures; see Serve
+func Start(t testing.TB) *httptest.Server {
+	t.Helper()
+	return Serve(t, Handler(t))
+}
+
+// Serve serves h and points the client at it. The test runs in a
+// directory of its own, where the session and the state files the
+// commands keep land.
+func Serve(t testing.TB, h http.Handler) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	t.Chdir(t.TempDir())
+	err := config.Init(config.Overrides{
+		ConfigPath: filepath.Join(t.TempDir(), "config.yaml"), // none: the defaults
+		BaseURL:    srv.URL + mock.BasePath,
+	})
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	return srv
+}
+
+// Login logs in as the seeded demo user
+func Login(t testing.TB) {
+	t.Helper()
+	if err := login.Login("demo@example.com", "demo"); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package mock
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+)
+
+//go:embed fixtures/*.yaml
+var defaultFixtures embed.FS
+
+// Seed is the fixture data the mock server starts from. Each field is read
+// from a file of the same name (tracks.json, albums.yaml, ...) in the seed dir.
+type Seed struct {
+	Tracks    []api.Track   `json:"tracks"`
+	Albums    []api.Album   `json:"albums"`
+	Artists   []api.Artist  `json:"artists"`
+	Lyrics    []Lyrics      `json:"lyrics"`
+	Users     []User        `json:"users"`
+	Libraries []SeedLibrary `json:"libraries"`
+	Favorites []string      `json:"favorites"` // track IDs
+	Queue     []string      `json:"queue"`     // track IDs
+}
+
+// Lyrics are matched on artist and title, like the real /lyrics endpoint
+type Lyrics struct {
+	Artist   string `json:"artist"`
+	Title    string `json:"title"`
+	Lyrics   string `json:"lyrics"`
+	Unsynced bool   `json:"unsynced"`
+}
+
+// User is an account the mock accepts at /auth/login
+type User struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// SeedLibrary is a library plus the IDs of the tracks in it
+type SeedLibrary struct {
+	api.Library
+	Tracks []string `json:"tracks"`
+}
+
+// DefaultSeed returns the small catalogue bundled with the binary
+func DefaultSeed() (*Seed, error) {
+	sub, err := fs.Sub(defaultFixtures, "fixtures")
+	if err != nil {
+		return nil, err
+	}
+	return LoadSeed(sub)
+}
+
+// LoadSeedDir reads a seed directory from disk
+func LoadSeedDir(dir string) (*Seed, error) {
+	return LoadSeed(os.DirFS(dir))
+}
+
+// LoadSeed reads every known fixture file present in fsys. Missing files
+// leave that part of the catalogue empty.
+func LoadSeed(fsys fs.FS) (*Seed, error) {
+	var seed Seed
+	parts := map[string]any{
+		"tracks":    &seed.Tracks,
+		"albums":    &seed.Albums,
+		"artists":   &seed.Artists,
+		"lyrics":    &seed.Lyrics,
+		"users":     &seed.Users,
+		"libraries": &seed.Libraries,
+		"favorites": &seed.Favorites,
+		"queue":     &seed.Queue,
+	}
+	for name, dst := range parts {
+		if err := readFixture(fsys, name, dst); err != nil {
+			return nil, err
+		}
+	}
+	return &seed, nil
+}
+
+// readFixture loads name.json, name.yaml or name.yml into dst. YAML is
+// converted to JSON first so the api types' json tags apply to both.
+func readFixture(fsys fs.FS, name string, dst any) error {
+	for _, ext := range []string{".json", ".yaml", ".yml"} {
+		data, err := fs.ReadFile(fsys, name+ext)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s%s: %v", name, ext, err)
+		}
+		if ext != ".json" {
+			var doc any
+			if err := yaml.Unmarshal(data, &doc); err != nil {
+				return fmt.Errorf("failed to parse %s%s: %v", name, ext, err)
+			}
+			if data, err = json.Marshal(doc); err != nil {
+				return fmt.Errorf("failed to convert %s%s: %v", name, ext, err)
+			}
+		}
+		if err := json.Unmarshal(data, dst); err != nil {
+			return fmt.Errorf("failed to decode %s%s: %v", name, ext, err)
+		}
+		return nil
+	}
+	return nil
+}
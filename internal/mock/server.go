@@ -0,0 +1,722 @@
+package mock
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+)
+
+// BasePath is where the API is mounted, matching the production layout
+const BasePath = "/api"
+
+// Server is an in-memory DAB backend implementing api.ServerInterface.
+// All state lives for the lifetime of the process.
+type Server struct {
+	mu sync.Mutex
+
+	tracks    []api.Track
+	albums    []api.Album
+	artists   []api.Artist
+	lyrics    []Lyrics
+	users     []User
+	libraries []*library
+	favorites []api.Track
+	queue     []api.Track
+
+	sessions map[string]User
+	nextLib  int
+}
+
+type library struct {
+	api.Library
+	tracks []api.Track
+}
+
+var _ api.ServerInterface = (*Server)(nil)
+
+// New builds a server from seed data
+func New(seed *Seed) *Server {
+	s := &Server{
+		tracks:   seed.Tracks,
+		albums:   seed.Albums,
+		artists:  seed.Artists,
+		lyrics:   seed.Lyrics,
+		users:    seed.Users,
+		sessions: make(map[string]User),
+	}
+	for _, l := range seed.Libraries {
+		lib := &library{Library: l.Library, tracks: s.resolve(l.Tracks)}
+		lib.TrackCount = models.Ptr(len(lib.tracks))
+		s.libraries = append(s.libraries, lib)
+	}
+	s.nextLib = len(s.libraries) + 1
+	s.favorites = s.resolve(seed.Favorites)
+	s.queue = s.resolve(seed.Queue)
+	return s
+}
+
+// Handler returns an http.Handler serving the API under BasePath plus the
+// fake audio files that /stream points at. Use it with httptest.NewServer.
+func (s *Server) Handler() http.Handler {
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	api.RegisterHandlersWithBaseURL(e, s, BasePath)
+	e.GET("/audio/:id", s.serveAudio)
+	e.GET("/covers/:name", serveCover)
+	return e
+}
+
+// ---------------------------------------------------------------------------
+// helpers
+// ---------------------------------------------------------------------------
+
+func errorJSON(ctx echo.Context, status int, msg string) error {
+	return ctx.JSON(status, api.Error{
+		Error:   models.Ptr(strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))),
+		Message: models.Ptr(msg),
+	})
+}
+
+func message(ctx echo.Context, status int, msg string) error {
+	return ctx.JSON(status, map[string]string{"message": msg})
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// currentUser returns the logged-in user, or false if the request has no valid session
+func (s *Server) currentUser(ctx echo.Context) (User, bool) {
+	cookie, err := ctx.Cookie("session")
+	if err != nil {
+		return User{}, false
+	}
+	u, ok := s.sessions[cookie.Value]
+	return u, ok
+}
+
+// requireUser writes a 401 and returns false when nobody is logged in
+func (s *Server) requireUser(ctx echo.Context) bool {
+	if _, ok := s.currentUser(ctx); !ok {
+		errorJSON(ctx, http.StatusUnauthorized, "not logged in")
+		return false
+	}
+	return true
+}
+
+func (s *Server) track(id string) (api.Track, bool) {
+	for _, t := range s.tracks {
+		if models.Value(t.Id) == id {
+			return t, true
+		}
+	}
+	return api.Track{}, false
+}
+
+func (s *Server) resolve(ids []string) []api.Track {
+	tracks := make([]api.Track, 0, len(ids))
+	for _, id := range ids {
+		if t, ok := s.track(id); ok {
+			tracks = append(tracks, t)
+		}
+	}
+	return tracks
+}
+
+// album returns the album with its tracklist and derived totals filled in
+func (s *Server) album(id string) (api.Album, bool) {
+	for _, a := range s.albums {
+		if models.Value(a.Id) != id {
+			continue
+		}
+		if a.Tracks == nil {
+			var tracks []api.Track
+			for _, t := range s.tracks {
+				if models.Value(t.AlbumId) == id {
+					tracks = append(tracks, t)
+				}
+			}
+			a.Tracks = &tracks
+		}
+		if a.TrackCount == nil {
+			a.TrackCount = models.Ptr(len(*a.Tracks))
+		}
+		if a.Duration == nil {
+			total := 0
+			for _, t := range *a.Tracks {
+				total += models.Value(t.Duration)
+			}
+			a.Duration = &total
+		}
+		return a, true
+	}
+	return api.Album{}, false
+}
+
+func (s *Server) library(id string) (*library, int) {
+	for i, l := range s.libraries {
+		if models.Value(l.Id) == id {
+			return l, i
+		}
+	}
+	return nil, -1
+}
+
+func contains(haystack *string, needle string) bool {
+	return strings.Contains(strings.ToLower(models.Value(haystack)), needle)
+}
+
+func apiUser(u User) *api.User {
+	return &api.User{
+		Id:       models.Ptr(u.ID),
+		Username: models.Ptr(u.Username),
+		Email:    models.Ptr(openapi_types.Email(u.Email)),
+	}
+}
+
+// ---------------------------------------------------------------------------
+// auth
+// ---------------------------------------------------------------------------
+
+func (s *Server) PostAuthLogin(ctx echo.Context) error {
+	var body api.PostAuthLoginJSONRequestBody
+	if err := ctx.Bind(&body); err != nil || body.Email == "" {
+		return errorJSON(ctx, http.StatusBadRequest, "email and password are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// With no seeded users any credentials are accepted
+	user := User{ID: 1, Username: strings.Split(string(body.Email), "@")[0], Email: string(body.Email)}
+	if len(s.users) > 0 {
+		found := false
+		for _, u := range s.users {
+			if u.Email == string(body.Email) && u.Password == body.Password {
+				user, found = u, true
+				break
+			}
+		}
+		if !found {
+			return errorJSON(ctx, http.StatusUnauthorized, "invalid email or password")
+		}
+	}
+
+	token := newToken()
+	s.sessions[token] = user
+	ctx.SetCookie(&http.Cookie{Name: "session", Value: token, Path: "/", HttpOnly: true})
+	return ctx.JSON(http.StatusOK, map[string]any{"message": "Login successful", "user": apiUser(user)})
+}
+
+func (s *Server) PostAuthLogout(ctx echo.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cookie, err := ctx.Cookie("session"); err == nil {
+		delete(s.sessions, cookie.Value)
+	}
+	ctx.SetCookie(&http.Cookie{Name: "session", Value: "", Path: "/", MaxAge: -1})
+	return message(ctx, http.StatusOK, "Logged out successfully")
+}
+
+func (s *Server) GetAuthMe(ctx echo.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.currentUser(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusOK, map[string]any{"user": nil})
+	}
+	return ctx.JSON(http.StatusOK, map[string]any{"user": apiUser(u)})
+}
+
+func (s *Server) PostAuthRegister(ctx echo.Context) error {
+	var body api.PostAuthRegisterJSONRequestBody
+	if err := ctx.Bind(&body); err != nil || body.Email == "" || body.Username == "" || body.Password == "" {
+		return errorJSON(ctx, http.StatusBadRequest, "username, email and password are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Email == string(body.Email) {
+			return errorJSON(ctx, http.StatusBadRequest, "email already registered")
+		}
+	}
+	s.users = append(s.users, User{
+		ID:       len(s.users) + 1,
+		Username: body.Username,
+		Email:    string(body.Email),
+		Password: body.Password,
+	})
+	return message(ctx, http.StatusCreated, "User created successfully")
+}
+
+func (s *Server) PostAuthForgotPassword(ctx echo.Context) error {
+	var body api.PostAuthForgotPasswordJSONRequestBody
+	if err := ctx.Bind(&body); err != nil || body.Email == "" {
+		return errorJSON(ctx, http.StatusBadRequest, "email is required")
+	}
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (s *Server) PostAuthResetPassword(ctx echo.Context) error {
+	var body api.PostAuthResetPasswordJSONRequestBody
+	if err := ctx.Bind(&body); err != nil || body.Token == "" || body.Password == "" {
+		return errorJSON(ctx, http.StatusBadRequest, "token and password are required")
+	}
+	return ctx.NoContent(http.StatusOK)
+}
+
+// ---------------------------------------------------------------------------
+// music
+// ---------------------------------------------------------------------------
+
+func (s *Server) GetSearch(ctx echo.Context, params api.GetSearchParams) error {
+	q := strings.ToLower(strings.TrimSpace(params.Q))
+	if q == "" {
+		return errorJSON(ctx, http.StatusBadRequest, "query is required")
+	}
+	limit := 20
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+	if limit < 1 || limit > 50 {
+		return errorJSON(ctx, http.StatusBadRequest, "limit must be between 1 and 50")
+	}
+	kind := api.GetSearchParamsTypeTrack
+	if params.Type != nil {
+		kind = *params.Type
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := []any{}
+	switch kind {
+	case api.GetSearchParamsTypeTrack:
+		for _, t := range s.tracks {
+			if contains(t.Title, q) || contains(t.Artist, q) || contains(t.AlbumTitle, q) {
+				results = append(results, t)
+			}
+		}
+	case api.GetSearchParamsTypeAlbum:
+		for _, a := range s.albums {
+			if contains(a.Title, q) || contains(a.Artist, q) {
+				results = append(results, a)
+			}
+		}
+	case api.GetSearchParamsTypeArtist:
+		for _, a := range s.artists {
+			if contains(a.Name, q) {
+				results = append(results, a)
+			}
+		}
+	default:
+		return errorJSON(ctx, http.StatusBadRequest, fmt.Sprintf("unknown search type %q", kind))
+	}
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return ctx.JSON(http.StatusOK, map[string]any{"results": results})
+}
+
+func (s *Server) GetAlbum(ctx echo.Context, params api.GetAlbumParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.album(params.AlbumId)
+	if !ok {
+		return errorJSON(ctx, http.StatusBadRequest, "album not found")
+	}
+	return ctx.JSON(http.StatusOK, map[string]any{"album": a})
+}
+
+func (s *Server) GetAlbumId(ctx echo.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.album(id)
+	if !ok {
+		return errorJSON(ctx, http.StatusNotFound, "album not found")
+	}
+	return ctx.JSON(http.StatusOK, map[string]any{"album": a})
+}
+
+func (s *Server) GetDiscography(ctx echo.Context, params api.GetDiscographyParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var artist *api.Artist
+	for i := range s.artists {
+		if models.Value(s.artists[i].Id) == params.ArtistId {
+			artist = &s.artists[i]
+		}
+	}
+	if artist == nil {
+		return errorJSON(ctx, http.StatusBadRequest, "artist not found")
+	}
+
+	albums := []api.Album{}
+	for _, a := range s.albums {
+		if models.Value(a.Artist) == models.Value(artist.Name) {
+			full, _ := s.album(models.Value(a.Id))
+			albums = append(albums, full)
+		}
+	}
+	return ctx.JSON(http.StatusOK, map[string]any{"artist": artist, "albums": albums})
+}
+
+func (s *Server) GetDownload(ctx echo.Context, params api.GetDownloadParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.album(params.AlbumId)
+	if !ok {
+		return errorJSON(ctx, http.StatusBadRequest, "album not found")
+	}
+	return ctx.JSON(http.StatusOK, map[string]any{"album": a})
+}
+
+func (s *Server) GetStream(ctx echo.Context, params api.GetStreamParams) error {
+	s.mu.Lock()
+	_, ok := s.track(params.TrackId)
+	s.mu.Unlock()
+	if !ok {
+		return errorJSON(ctx, http.StatusBadRequest, "track not found")
+	}
+
+	url := fmt.Sprintf("%s://%s/audio/%s", ctx.Scheme(), ctx.Request().Host, params.TrackId)
+	if q := models.Value(params.Quality); q != "" {
+		url += "?quality=" + q
+	}
+	return ctx.JSON(http.StatusOK, map[string]string{"streamUrl": url})
+}
+
+func (s *Server) GetLyrics(ctx echo.Context, params api.GetLyricsParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, l := range s.lyrics {
+		if strings.EqualFold(l.Artist, params.Artist) && strings.EqualFold(l.Title, params.Title) {
+			return ctx.JSON(http.StatusOK, map[string]any{"lyrics": l.Lyrics, "unsynced": l.Unsynced})
+		}
+	}
+	return errorJSON(ctx, http.StatusNotFound, "lyrics not found")
+}
+
+// serveAudio answers the URLs handed out by /stream. Lossless qualities
+// get FLAC, MP3 320 gets MP3 and no quality gets WAV. http.ServeContent
+// gives us Range support for free.
+func (s *Server) serveAudio(ctx echo.Context) error {
+	s.mu.Lock()
+	t, ok := s.track(ctx.Param("id"))
+	s.mu.Unlock()
+	if !ok {
+		return errorJSON(ctx, http.StatusNotFound, "track not found")
+	}
+
+	name, body := models.Value(t.Id)+".wav", toneWAV(t)
+	contentType := "audio/wav"
+	switch ctx.QueryParam("quality") {
+	case "":
+	case "5":
+		name, body, contentType = models.Value(t.Id)+".mp3", silenceMP3(t), "audio/mpeg"
+	default:
+		name, body, contentType = models.Value(t.Id)+".flac", toneFLAC(t), "audio/flac"
+	}
+	ctx.Response().Header().Set(echo.HeaderContentType, contentType)
+	http.ServeContent(ctx.Response(), ctx.Request(), name, time.Time{}, body)
+	return nil
+}
+
+// serveCover answers the relative cover URLs in the fixtures
+func serveCover(ctx echo.Context) error {
+	return ctx.Blob(http.StatusOK, "image/png", coverPNG(ctx.Param("name")))
+}
+
+// ---------------------------------------------------------------------------
+// favorites
+// ---------------------------------------------------------------------------
+
+func (s *Server) GetFavorites(ctx echo.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireUser(ctx) {
+		return nil
+	}
+	return ctx.JSON(http.StatusOK, map[string]any{"favorites": s.favorites})
+}
+
+func (s *Server) PostFavorites(ctx echo.Context) error {
+	var body api.PostFavoritesJSONRequestBody
+	if err := ctx.Bind(&body); err != nil || models.Value(body.Track.Id) == "" {
+		return errorJSON(ctx, http.StatusBadRequest, "track with id is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireUser(ctx) {
+		return nil
+	}
+	for _, t := range s.favorites {
+		if models.Value(t.Id) == *body.Track.Id {
+			return message(ctx, http.StatusOK, "Already in favorites")
+		}
+	}
+	s.favorites = append(s.favorites, body.Track)
+	return message(ctx, http.StatusCreated, "Added to favorites")
+}
+
+func (s *Server) DeleteFavorites(ctx echo.Context, params api.DeleteFavoritesParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireUser(ctx) {
+		return nil
+	}
+	for i, t := range s.favorites {
+		if models.Value(t.Id) == params.TrackId {
+			s.favorites = append(s.favorites[:i], s.favorites[i+1:]...)
+			break
+		}
+	}
+	return message(ctx, http.StatusOK, "Removed from favorites")
+}
+
+// ---------------------------------------------------------------------------
+// libraries
+// ---------------------------------------------------------------------------
+
+func (s *Server) GetLibraries(ctx echo.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireUser(ctx) {
+		return nil
+	}
+	libs := make([]api.Library, 0, len(s.libraries))
+	for _, l := range s.libraries {
+		libs = append(libs, l.Library)
+	}
+	return ctx.JSON(http.StatusOK, map[string]any{"libraries": libs})
+}
+
+func (s *Server) PostLibraries(ctx echo.Context) error {
+	var body api.PostLibrariesJSONRequestBody
+	if err := ctx.Bind(&body); err != nil || strings.TrimSpace(body.Name) == "" {
+		return errorJSON(ctx, http.StatusBadRequest, "name is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireUser(ctx) {
+		return nil
+	}
+	lib := &library{Library: api.Library{
+		Id:          models.Ptr(fmt.Sprintf("lib%d", s.nextLib)),
+		Name:        models.Ptr(body.Name),
+		Description: body.Description,
+		IsPublic:    models.Ptr(models.Value(body.IsPublic)),
+		CreatedAt:   models.Ptr(time.Now().UTC()),
+		TrackCount:  models.Ptr(0),
+	}}
+	s.nextLib++
+	s.libraries = append(s.libraries, lib)
+	return ctx.JSON(http.StatusCreated, map[string]any{"message": "Library created", "library": lib.Library})
+}
+
+func (s *Server) GetLibrariesId(ctx echo.Context, id string, params api.GetLibrariesIdParams) error {
+	page, limit := 1, 20
+	if params.Page != nil && *params.Page > 0 {
+		page = *params.Page
+	}
+	if params.Limit != nil && *params.Limit > 0 {
+		limit = min(*params.Limit, 100)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireUser(ctx) {
+		return nil
+	}
+	lib, _ := s.library(id)
+	if lib == nil {
+		return errorJSON(ctx, http.StatusNotFound, "library not found")
+	}
+
+	total := len(lib.tracks)
+	start := min((page-1)*limit, total)
+	end := min(start+limit, total)
+	tracks := lib.tracks[start:end]
+
+	body := map[string]any{
+		"library": map[string]any{
+			"id":          lib.Id,
+			"name":        lib.Name,
+			"description": lib.Description,
+			"isPublic":    lib.IsPublic,
+			"createdAt":   lib.CreatedAt,
+			"trackCount":  total,
+			"tracks":      tracks,
+			"pagination": api.Pagination{
+				Page:    models.Ptr(page),
+				Limit:   models.Ptr(limit),
+				Total:   models.Ptr(total),
+				Loaded:  models.Ptr(end),
+				HasMore: models.Ptr(end < total),
+			},
+		},
+	}
+	return ctx.JSON(http.StatusOK, body)
+}
+
+func (s *Server) PatchLibrariesId(ctx echo.Context, id string) error {
+	var body api.PatchLibrariesIdJSONRequestBody
+	if err := ctx.Bind(&body); err != nil {
+		return errorJSON(ctx, http.StatusBadRequest, "invalid body")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireUser(ctx) {
+		return nil
+	}
+	lib, _ := s.library(id)
+	if lib == nil {
+		return errorJSON(ctx, http.StatusNotFound, "library not found")
+	}
+	if body.Name != nil {
+		lib.Name = body.Name
+	}
+	if body.Description != nil {
+		lib.Description = body.Description
+	}
+	if body.IsPublic != nil {
+		lib.IsPublic = body.IsPublic
+	}
+	return message(ctx, http.StatusOK, "Library updated")
+}
+
+func (s *Server) DeleteLibrariesId(ctx echo.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireUser(ctx) {
+		return nil
+	}
+	_, i := s.library(id)
+	if i < 0 {
+		return errorJSON(ctx, http.StatusNotFound, "library not found")
+	}
+	s.libraries = append(s.libraries[:i], s.libraries[i+1:]...)
+	return message(ctx, http.StatusOK, "Library deleted")
+}
+
+func (s *Server) PostLibrariesIdTracks(ctx echo.Context, id string) error {
+	var body api.PostLibrariesIdTracksJSONRequestBody
+	if err := ctx.Bind(&body); err != nil || models.Value(body.Track.Id) == "" {
+		return errorJSON(ctx, http.StatusBadRequest, "track with id is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireUser(ctx) {
+		return nil
+	}
+	lib, _ := s.library(id)
+	if lib == nil {
+		return errorJSON(ctx, http.StatusNotFound, "library not found")
+	}
+	for _, t := range lib.tracks {
+		if models.Value(t.Id) == *body.Track.Id {
+			return message(ctx, http.StatusOK, "Track already exists in library")
+		}
+	}
+	lib.tracks = append(lib.tracks, body.Track)
+	lib.TrackCount = models.Ptr(len(lib.tracks))
+	return message(ctx, http.StatusCreated, "Track added to library")
+}
+
+func (s *Server) DeleteLibrariesIdTracksTrackId(ctx echo.Context, id string, trackId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireUser(ctx) {
+		return nil
+	}
+	lib, _ := s.library(id)
+	if lib == nil {
+		return errorJSON(ctx, http.StatusNotFound, "library not found")
+	}
+	for i, t := range lib.tracks {
+		if models.Value(t.Id) == trackId {
+			lib.tracks = append(lib.tracks[:i], lib.tracks[i+1:]...)
+			lib.TrackCount = models.Ptr(len(lib.tracks))
+			return message(ctx, http.StatusOK, "Track removed from library")
+		}
+	}
+	return errorJSON(ctx, http.StatusNotFound, "track not in library")
+}
+
+// ---------------------------------------------------------------------------
+// queue
+// ---------------------------------------------------------------------------
+
+func (s *Server) GetQueue(ctx echo.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireUser(ctx) {
+		return nil
+	}
+	return ctx.JSON(http.StatusOK, map[string]any{"queue": s.queue})
+}
+
+func (s *Server) PostQueue(ctx echo.Context) error {
+	var body api.PostQueueJSONRequestBody
+	if err := ctx.Bind(&body); err != nil {
+		return errorJSON(ctx, http.StatusBadRequest, "invalid body")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireUser(ctx) {
+		return nil
+	}
+	s.queue = append([]api.Track{}, body.Queue...)
+	return message(ctx, http.StatusOK, "Queue saved")
+}
+
+func (s *Server) DeleteQueue(ctx echo.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireUser(ctx) {
+		return nil
+	}
+	s.queue = []api.Track{}
+	return message(ctx, http.StatusOK, "Queue cleared")
+}
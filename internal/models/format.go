@@ -0,0 +1,69 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+)
+
+// Duration formats seconds as m:ss, or h:mm:ss for an hour or more
+func Duration(seconds int) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	h, m, s := seconds/3600, seconds/60%60, seconds%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// Bytes formats a size like "4.2 MB"
+func Bytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// Quality describes an AudioQuality like "24-bit / 96 kHz (Hi-Res)"
+func Quality(q *api.AudioQuality) string {
+	if q == nil || (q.MaximumBitDepth == nil && q.MaximumSamplingRate == nil) {
+		return "unknown"
+	}
+	s := fmt.Sprintf("%d-bit / %s kHz",
+		Value(q.MaximumBitDepth),
+		strconv.FormatFloat(float64(Value(q.MaximumSamplingRate)), 'f', -1, 32))
+	if Value(q.IsHiRes) {
+		s += " (Hi-Res)"
+	}
+	return s
+}
+
+// AlbumDuration is the album's total length, summing its tracks when
+// the API leaves it out
+func AlbumDuration(a api.Album) int {
+	if a.Duration != nil {
+		return *a.Duration
+	}
+	total := 0
+	for _, t := range Value(a.Tracks) {
+		total += Value(t.Duration)
+	}
+	return total
+}
+
+// AlbumTrackCount is the album's track count, falling back to the tracklist length
+func AlbumTrackCount(a api.Album) int {
+	if a.TrackCount != nil {
+		return *a.TrackCount
+	}
+	return len(Value(a.Tracks))
+}
@@ -1,6 +0,0 @@
-package models
-
-type StreamResponse struct {
-	URL string `json:"url"`
-}
-
@@ -0,0 +1,15 @@
+package models
+
+// Value dereferences an optional API field, returning the zero value when unset
+func Value[T any](p *T) T {
+	if p == nil {
+		var zero T
+		return zero
+	}
+	return *p
+}
+
+// Ptr returns a pointer to v, for filling optional API fields
+func Ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,292 @@
+package mpv
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// ErrUnavailable is returned for a property mpv has no value for yet,
+// e.g. time-pos before playback starts
+var ErrUnavailable = errors.New("property unavailable")
+
+// ErrClosed is returned once the connection to mpv is gone
+var ErrClosed = errors.New("mpv connection closed")
+
+// Event is an unsolicited message from mpv, e.g. "property-change" for
+// an observed property or "end-file"
+type Event struct {
+	Name   string          `json:"event"`
+	ID     int64           `json:"id,omitempty"`   // observer ID of a property-change
+	Prop   string          `json:"name,omitempty"` // property of a property-change
+	Data   json.RawMessage `json:"data,omitempty"`
+	Reason string          `json:"reason,omitempty"` // why an end-file happened
+}
+
+// message is any line mpv sends: a reply carries a request_id, an
+// event an event name
+type message struct {
+	Event
+	RequestID *int64 `json:"request_id"`
+	Error     string `json:"error"`
+}
+
+type reply struct {
+	data json.RawMessage
+	err  error
+}
+
+// Client speaks mpv's JSON IPC protocol to one mpv instance, over the
+// socket it opens with --input-ipc-server
+type Client struct {
+	conn net.Conn
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan reply
+	closed  bool
+
+	events chan Event
+	done   chan struct{}
+}
+
+// Dial connects to mpv's IPC socket, waiting up to timeout for mpv to
+// create it
+func Dial(path string, timeout time.Duration) (*Client, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.Dial("unix", path)
+		if err == nil {
+			return newClient(conn), nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to connect to mpv: %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func newClient(conn net.Conn) *Client {
+	c := &Client{
+		conn:    conn,
+		pending: map[int64]chan reply{},
+		events:  make(chan Event, 32),
+		done:    make(chan struct{}),
+	}
+	go c.read()
+	return c
+}
+
+// read dispatches replies to their callers and events to Events
+func (c *Client) read() {
+	scanner := bufio.NewScanner(c.conn)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		if msg.RequestID == nil {
+			if msg.Name != "" {
+				select {
+				case c.events <- msg.Event:
+				default: // nobody listening; drop it
+				}
+			}
+			continue
+		}
+
+		c.mu.Lock()
+		ch, ok := c.pending[*msg.RequestID]
+		delete(c.pending, *msg.RequestID)
+		c.mu.Unlock()
+		if !ok {
+			continue
+		}
+		r := reply{data: msg.Data}
+		switch msg.Error {
+		case "success":
+		case "property unavailable":
+			r.err = ErrUnavailable
+		default:
+			r.err = fmt.Errorf("mpv: %s", msg.Error)
+		}
+		ch <- r
+	}
+
+	c.mu.Lock()
+	c.closed = true
+	for id, ch := range c.pending {
+		ch <- reply{err: ErrClosed}
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+	close(c.events)
+	close(c.done)
+}
+
+// Command runs an mpv input command, e.g. Command("seek", 10, "relative"),
+// and returns its result
+func (c *Client) Command(args ...any) (json.RawMessage, error) {
+	ch := make(chan reply, 1)
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrClosed
+	}
+	c.nextID++
+	id := c.nextID
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	line, err := json.Marshal(map[string]any{"command": args, "request_id": id})
+	if err != nil {
+		return nil, fmt.Errorf("mpv: %v", err)
+	}
+	if _, err := c.conn.Write(append(line, '\n')); err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return nil, ErrClosed
+	}
+	r := <-ch
+	return r.data, r.err
+}
+
+// Get reads a property into v
+func (c *Client) Get(prop string, v any) error {
+	data, err := c.Command("get_property", prop)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("mpv: bad %s value: %v", prop, err)
+	}
+	return nil
+}
+
+// Set writes a property
+func (c *Client) Set(prop string, v any) error {
+	_, err := c.Command("set_property", prop, v)
+	return err
+}
+
+// Observe asks mpv to report changes to prop as property-change events
+// carrying id
+func (c *Client) Observe(id int64, prop string) error {
+	_, err := c.Command("observe_property", id, prop)
+	return err
+}
+
+// Pause pauses playback
+func (c *Client) Pause() error {
+	return c.Set("pause", true)
+}
+
+// Resume resumes paused playback
+func (c *Client) Resume() error {
+	return c.Set("pause", false)
+}
+
+// TogglePause pauses or resumes
+func (c *Client) TogglePause() error {
+	_, err := c.Command("cycle", "pause")
+	return err
+}
+
+// Seek jumps to seconds from the start, or by seconds from the current
+// position if relative
+func (c *Client) Seek(seconds float64, relative bool) error {
+	mode := "absolute"
+	if relative {
+		mode = "relative"
+	}
+	_, err := c.Command("seek", seconds, mode)
+	return err
+}
+
+// SetVolume sets the volume in percent (0-100, more amplifies)
+func (c *Client) SetVolume(percent float64) error {
+	return c.Set("volume", percent)
+}
+
+// AddVolume changes the volume by delta percent
+func (c *Client) AddVolume(delta float64) error {
+	_, err := c.Command("add", "volume", delta)
+	return err
+}
+
+// SetMute mutes or unmutes
+func (c *Client) SetMute(mute bool) error {
+	return c.Set("mute", mute)
+}
+
+// ToggleMute mutes or unmutes
+func (c *Client) ToggleMute() error {
+	_, err := c.Command("cycle", "mute")
+	return err
+}
+
+// Position is the playback position in seconds
+func (c *Client) Position() (float64, error) {
+	return c.float("time-pos")
+}
+
+// Duration is the length of the file in seconds
+func (c *Client) Duration() (float64, error) {
+	return c.float("duration")
+}
+
+// Volume is the volume in percent
+func (c *Client) Volume() (float64, error) {
+	return c.float("volume")
+}
+
+// Paused reports whether playback is paused
+func (c *Client) Paused() (bool, error) {
+	var v bool
+	err := c.Get("pause", &v)
+	return v, err
+}
+
+// Muted reports whether audio is muted
+func (c *Client) Muted() (bool, error) {
+	var v bool
+	err := c.Get("mute", &v)
+	return v, err
+}
+
+func (c *Client) float(prop string) (float64, error) {
+	var v float64
+	err := c.Get(prop, &v)
+	return v, err
+}
+
+// Quit tells mpv to exit
+func (c *Client) Quit() error {
+	_, err := c.Command("quit")
+	if errors.Is(err, ErrClosed) {
+		return nil // it went before answering
+	}
+	return err
+}
+
+// Events delivers mpv's events until the connection closes. Events are
+// dropped while nobody reads them.
+func (c *Client) Events() <-chan Event {
+	return c.events
+}
+
+// Done is closed when the connection to mpv is gone
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close drops the connection; mpv keeps running
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
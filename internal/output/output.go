@@ -0,0 +1,137 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// Formats lists the named formats; anything containing "{{" is a template
+var Formats = []string{"text", "json", "ndjson", "csv", "tsv"}
+
+// Result is a command's output in a shape every format can render
+type Result struct {
+	value  any        // encoded whole by json
+	items  []any      // one per ndjson line, table row and template run
+	header []string   // csv/tsv column names
+	rows   [][]string // csv/tsv cells, parallel to items
+	text   func(w io.Writer)
+}
+
+// Printer renders results in one format
+type Printer struct {
+	format string
+	tmpl   *template.Template
+}
+
+// New validates a --format value. An empty format means human-readable text.
+func New(format string) (*Printer, error) {
+	if format == "" {
+		format = "text"
+	}
+	if strings.Contains(format, "{{") {
+		tmpl, err := template.New("format").Funcs(funcs).Parse(format)
+		if err != nil {
+			return nil, fmt.Errorf("invalid format template: %v", err)
+		}
+		return &Printer{format: "template", tmpl: tmpl}, nil
+	}
+	for _, f := range Formats {
+		if f == format {
+			return &Printer{format: format}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown format %q (want %s or a Go template)", format, strings.Join(Formats, ", "))
+}
+
+// IsText reports whether the printer produces human-readable output,
+// so commands know whether chatty messages are welcome
+func (p *Printer) IsText() bool {
+	return p.format == "text"
+}
+
+// Print writes r to w
+func (p *Printer) Print(w io.Writer, r Result) error {
+	switch p.format {
+	case "text":
+		r.text(w)
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r.value)
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, item := range r.items {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(r.header)
+		cw.WriteAll(r.rows)
+		return cw.Error()
+	case "tsv":
+		// Plain TSV without quoting, so the output splits cleanly with cut/awk
+		clean := strings.NewReplacer("\t", " ", "\n", " ", "\r", "")
+		for _, row := range append([][]string{r.header}, r.rows...) {
+			cells := make([]string, len(row))
+			for i, c := range row {
+				cells[i] = clean.Replace(c)
+			}
+			if _, err := fmt.Fprintln(w, strings.Join(cells, "\t")); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "template":
+		for _, item := range r.items {
+			if err := p.tmpl.Execute(w, item); err != nil {
+				return fmt.Errorf("failed to render template: %v", err)
+			}
+			fmt.Fprintln(w)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown format %q", p.format)
+}
+
+// Template helpers. Optional API fields are pointers, which templates
+// print as "<nil>" when unset; "value" prints an empty string instead.
+var funcs = template.FuncMap{
+	"value": func(v any) any {
+		switch p := v.(type) {
+		case *string:
+			if p == nil {
+				return ""
+			}
+			return *p
+		case *int:
+			if p == nil {
+				return ""
+			}
+			return *p
+		case *bool:
+			if p == nil {
+				return ""
+			}
+			return *p
+		case *float32:
+			if p == nil {
+				return ""
+			}
+			return *p
+		}
+		return v
+	},
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join": strings.Join,
+}
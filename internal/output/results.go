@@ -0,0 +1,325 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/artist"
+	"github.com/adityadeshmukh1/dab-cli/internal/library"
+	"github.com/adityadeshmukh1/dab-cli/internal/lyrics"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+)
+
+var (
+	trackHeader = []string{"id", "title", "artist", "album", "album_id", "artist_id", "duration",
+		"genre", "release_date", "bit_depth", "sampling_rate", "hires"}
+	albumHeader = []string{"id", "title", "artist", "release_date", "label", "genre", "upc",
+		"track_count", "duration", "bit_depth", "sampling_rate", "hires"}
+	artistHeader  = []string{"id", "name", "albums_count", "similar_artist_ids"}
+	libraryHeader = []string{"id", "name", "description", "public", "track_count", "created_at"}
+	userHeader    = []string{"id", "username", "email"}
+	lyricsHeader  = []string{"artist", "title", "synced", "lyrics"}
+)
+
+// Tracks renders a list of tracks: search results, favorites, a queue...
+func Tracks(tracks []api.Track) Result {
+	return TracksFrom(tracks, 1)
+}
+
+// TracksFrom renders a page of a longer list of tracks, numbered from first
+func TracksFrom(tracks []api.Track, first int) Result {
+	if tracks == nil {
+		tracks = []api.Track{}
+	}
+	r := Result{value: tracks, header: trackHeader}
+	for _, t := range tracks {
+		r.items = append(r.items, t)
+		r.rows = append(r.rows, trackRow(t))
+	}
+	r.text = func(w io.Writer) {
+		if len(tracks) == 0 {
+			fmt.Fprintln(w, "No tracks found.")
+			return
+		}
+		printTracks(w, tracks, first)
+	}
+	return r
+}
+
+// Album renders one album; json includes the tracklist
+func Album(a api.Album) Result {
+	return Result{
+		value:  a,
+		items:  []any{a},
+		header: albumHeader,
+		rows:   [][]string{albumRow(a)},
+		text: func(w io.Writer) {
+			fmt.Fprintf(w, "%s - %s\n", models.Value(a.Title), models.Value(a.Artist))
+			fmt.Fprintf(w, "Released: %s  Label: %s  Genre: %s  UPC: %s\n",
+				models.Value(a.ReleaseDate), models.Value(a.Label), models.Value(a.Genre), models.Value(a.Upc))
+			fmt.Fprintf(w, "%d tracks, %s  Quality: %s\n",
+				models.AlbumTrackCount(a), models.Duration(models.AlbumDuration(a)), models.Quality(a.AudioQuality))
+			fmt.Fprintln(w)
+			for i, t := range models.Value(a.Tracks) {
+				fmt.Fprintf(w, "%2d. %-40s %6s [%s]\n", i+1, models.Value(t.Title),
+					models.Duration(models.Value(t.Duration)), models.Value(t.Id))
+			}
+		},
+	}
+}
+
+// Albums renders a list of albums
+func Albums(albums []api.Album) Result {
+	if albums == nil {
+		albums = []api.Album{}
+	}
+	r := Result{value: albums, header: albumHeader}
+	for _, a := range albums {
+		r.items = append(r.items, a)
+		r.rows = append(r.rows, albumRow(a))
+	}
+	r.text = func(w io.Writer) {
+		if len(albums) == 0 {
+			fmt.Fprintln(w, "No albums found.")
+			return
+		}
+		for i, a := range albums {
+			fmt.Fprintf(w, "%2d. %s - %s (%s) [%s]\n", i+1, models.Value(a.Title), models.Value(a.Artist),
+				year(a.ReleaseDate), models.Value(a.Id))
+		}
+	}
+	return r
+}
+
+// Artists renders a list of artists
+func Artists(artists []api.Artist) Result {
+	if artists == nil {
+		artists = []api.Artist{}
+	}
+	r := Result{value: artists, header: artistHeader}
+	for _, a := range artists {
+		r.items = append(r.items, a)
+		r.rows = append(r.rows, artistRow(a))
+	}
+	r.text = func(w io.Writer) {
+		if len(artists) == 0 {
+			fmt.Fprintln(w, "No artists found.")
+			return
+		}
+		for i, a := range artists {
+			fmt.Fprintf(w, "%2d. %s [%s]\n", i+1, models.Value(a.Name), models.Value(a.Id))
+		}
+	}
+	return r
+}
+
+// Discography renders an artist with their albums, already sorted.
+// Tables and templates get one entry per album, with its release type;
+// json mirrors the /discography response. grouped splits the text
+// listing by release type.
+func Discography(ar api.Artist, albums []api.Album, grouped bool) Result {
+	r := Albums(albums)
+	r.value = map[string]any{"artist": ar, "albums": r.value}
+	r.header = append(append([]string(nil), albumHeader...), "type")
+	for i, a := range albums {
+		r.rows[i] = append(r.rows[i], artist.AlbumType(a))
+	}
+	r.text = func(w io.Writer) {
+		fmt.Fprintln(w, models.Value(ar.Name))
+		fmt.Fprintf(w, "%d albums, %d as primary artist\n",
+			models.Value(ar.AlbumsCount), models.Value(ar.AlbumsAsPrimaryArtistCount))
+		if bio := models.Value(ar.Biography); bio != "" {
+			fmt.Fprintf(w, "\n%s\n", bio)
+		}
+		if !grouped {
+			fmt.Fprintln(w)
+			printAlbums(w, albums)
+			return
+		}
+		for _, g := range artist.GroupAlbums(albums) {
+			fmt.Fprintf(w, "\n%ss\n", g.Type)
+			printAlbums(w, g.Albums)
+		}
+	}
+	return r
+}
+
+// Libraries renders the user's libraries
+func Libraries(libs []api.Library) Result {
+	if libs == nil {
+		libs = []api.Library{}
+	}
+	r := Result{value: libs, header: libraryHeader}
+	for _, l := range libs {
+		r.items = append(r.items, l)
+		r.rows = append(r.rows, libraryRow(l))
+	}
+	r.text = func(w io.Writer) {
+		if len(libs) == 0 {
+			fmt.Fprintln(w, "No libraries yet.")
+			return
+		}
+		for _, l := range libs {
+			fmt.Fprintf(w, "%s  %s (%d tracks)\n", models.Value(l.Id), models.Value(l.Name), models.Value(l.TrackCount))
+		}
+	}
+	return r
+}
+
+// Library renders one library; tables and templates get its tracks
+func Library(lib library.Library) Result {
+	// Number the tracks of a later page on from the earlier ones
+	info := lib.Pagination
+	first := (max(models.Value(info.Page), 1)-1)*models.Value(info.Limit) + 1
+	r := TracksFrom(lib.Tracks, first)
+	r.value = lib
+	r.text = func(w io.Writer) {
+		fmt.Fprintln(w, models.Value(lib.Name))
+		if desc := models.Value(lib.Description); desc != "" {
+			fmt.Fprintln(w, desc)
+		}
+		fmt.Fprintln(w)
+		printTracks(w, lib.Tracks, first)
+	}
+	return r
+}
+
+// Queue renders the local play queue, marking the current track.
+// current is 0-based and may be past the end once the queue played through.
+func Queue(tracks []api.Track, current int, repeat string) Result {
+	r := Tracks(tracks)
+	r.value = struct {
+		Tracks  []api.Track `json:"tracks"`
+		Current int         `json:"current"`
+		Repeat  string      `json:"repeat"`
+	}{r.value.([]api.Track), current, repeat}
+	r.text = func(w io.Writer) {
+		if len(tracks) == 0 {
+			fmt.Fprintln(w, "The queue is empty.")
+			return
+		}
+		for i, t := range tracks {
+			mark := "  "
+			if i == current {
+				mark = "> "
+			}
+			fmt.Fprintf(w, "%s%2d. %s - %s [%s]\n", mark, i+1, models.Value(t.Title), models.Value(t.Artist), models.Value(t.Id))
+		}
+		fmt.Fprintf(w, "\nRepeat: %s\n", repeat)
+	}
+	return r
+}
+
+// User renders an account
+func User(u api.User) Result {
+	return Result{
+		value:  u,
+		items:  []any{u},
+		header: userHeader,
+		rows: [][]string{{
+			intString(u.Id), models.Value(u.Username), string(models.Value(u.Email)),
+		}},
+		text: func(w io.Writer) {
+			fmt.Fprintf(w, "%s <%s>\n", models.Value(u.Username), models.Value(u.Email))
+		},
+	}
+}
+
+// Lyrics renders a song's lyrics
+func Lyrics(l lyrics.Lyrics) Result {
+	return Result{
+		value:  l,
+		items:  []any{l},
+		header: lyricsHeader,
+		rows:   [][]string{{l.Artist, l.Title, strconv.FormatBool(l.Synced), l.Text}},
+		text: func(w io.Writer) {
+			fmt.Fprintln(w, strings.TrimRight(l.Text, "\n"))
+		},
+	}
+}
+
+func printTracks(w io.Writer, tracks []api.Track, first int) {
+	for i, t := range tracks {
+		fmt.Fprintf(w, "%2d. %s - %s [%s]\n", first+i, models.Value(t.Title), models.Value(t.Artist), models.Value(t.Id))
+	}
+}
+
+func printAlbums(w io.Writer, albums []api.Album) {
+	for _, a := range albums {
+		fmt.Fprintf(w, "%-12s %s (%d tracks) [%s]\n", models.Value(a.ReleaseDate), models.Value(a.Title),
+			models.AlbumTrackCount(a), models.Value(a.Id))
+	}
+}
+
+// year trims an API release date ("2021-03-14") to its year
+func year(date *string) string {
+	d := models.Value(date)
+	if len(d) >= 4 {
+		return d[:4]
+	}
+	return d
+}
+
+func trackRow(t api.Track) []string {
+	q := models.Value(t.AudioQuality)
+	return []string{
+		models.Value(t.Id), models.Value(t.Title), models.Value(t.Artist), models.Value(t.AlbumTitle),
+		models.Value(t.AlbumId), models.Value(t.ArtistId), intString(t.Duration), models.Value(t.Genre),
+		models.Value(t.ReleaseDate), intString(q.MaximumBitDepth), floatString(q.MaximumSamplingRate),
+		boolString(q.IsHiRes),
+	}
+}
+
+func albumRow(a api.Album) []string {
+	q := models.Value(a.AudioQuality)
+	return []string{
+		models.Value(a.Id), models.Value(a.Title), models.Value(a.Artist), models.Value(a.ReleaseDate),
+		models.Value(a.Label), models.Value(a.Genre), models.Value(a.Upc), intString(a.TrackCount),
+		intString(a.Duration), intString(q.MaximumBitDepth), floatString(q.MaximumSamplingRate),
+		boolString(q.IsHiRes),
+	}
+}
+
+func artistRow(a api.Artist) []string {
+	return []string{
+		models.Value(a.Id), models.Value(a.Name), intString(a.AlbumsCount),
+		strings.Join(models.Value(a.SimilarArtistIds), ";"),
+	}
+}
+
+func libraryRow(l api.Library) []string {
+	created := ""
+	if l.CreatedAt != nil {
+		created = l.CreatedAt.Format(time.RFC3339)
+	}
+	return []string{
+		models.Value(l.Id), models.Value(l.Name), models.Value(l.Description),
+		boolString(l.IsPublic), intString(l.TrackCount), created,
+	}
+}
+
+// Unset optional fields become empty cells rather than zeros
+func intString(p *int) string {
+	if p == nil {
+		return ""
+	}
+	return strconv.Itoa(*p)
+}
+
+func floatString(p *float32) string {
+	if p == nil {
+		return ""
+	}
+	return strconv.FormatFloat(float64(*p), 'f', -1, 32)
+}
+
+func boolString(p *bool) string {
+	if p == nil {
+		return ""
+	}
+	return strconv.FormatBool(*p)
+}
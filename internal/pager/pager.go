@@ -0,0 +1,146 @@
+// Package pager walks lists the API serves a page at a time
+package pager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+)
+
+// DefaultLimit is the page size when none is given
+const DefaultLimit = 20
+
+// Page is one page of a list, numbered from 1
+type Page[T any] struct {
+	Number     int
+	Items      []T
+	Pagination api.Pagination // as the server sent it; any field may be unset
+}
+
+// Single is a whole list as one page
+func Single[T any](items []T) Page[T] {
+	n := len(items)
+	return Page[T]{Number: 1, Items: items, Pagination: api.Pagination{
+		Page:    models.Ptr(1),
+		Limit:   models.Ptr(n),
+		Total:   models.Ptr(n),
+		Loaded:  models.Ptr(n),
+		HasMore: models.Ptr(false),
+	}}
+}
+
+// Fetch gets page number page, of at most limit items
+type Fetch[T any] func(ctx context.Context, page, limit int) (Page[T], error)
+
+// Pager collects the pages of a list in order. Fetch may be called from
+// any goroutine; Add and the rest belong to the one that owns the pager.
+type Pager[T any] struct {
+	fetch Fetch[T]
+	limit int
+	items []T
+	pages int // loaded so far
+	total int // -1 while unknown
+	more  bool
+}
+
+// New returns a pager over fetch. A zero limit uses DefaultLimit.
+func New[T any](fetch Fetch[T], limit int) *Pager[T] {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	return &Pager[T]{fetch: fetch, limit: limit, total: -1, more: true}
+}
+
+// Limit is the page size
+func (p *Pager[T]) Limit() int {
+	return p.limit
+}
+
+// Fetch gets one page without adding it
+func (p *Pager[T]) Fetch(ctx context.Context, page int) (Page[T], error) {
+	return p.fetch(ctx, page, p.limit)
+}
+
+// NextPage is the number of the page to load next
+func (p *Pager[T]) NextPage() int {
+	return p.pages + 1
+}
+
+// Add appends a fetched page. Anything but the next page is dropped, so
+// a page fetched twice is only added once.
+func (p *Pager[T]) Add(pg Page[T]) bool {
+	if pg.Number != p.NextPage() {
+		return false
+	}
+	p.pages++
+	p.items = append(p.items, pg.Items...)
+
+	// Without hasMore a full page suggests there is another
+	info := pg.Pagination
+	if info.HasMore != nil {
+		p.more = *info.HasMore
+	} else {
+		p.more = len(pg.Items) >= p.limit
+	}
+	if len(pg.Items) == 0 {
+		p.more = false
+	}
+	if info.Total != nil {
+		p.total = *info.Total
+	}
+	if !p.more {
+		p.total = len(p.items)
+	}
+	return true
+}
+
+// Next loads the next page
+func (p *Pager[T]) Next(ctx context.Context) error {
+	pg, err := p.Fetch(ctx, p.NextPage())
+	if err != nil {
+		return err
+	}
+	p.Add(pg)
+	return nil
+}
+
+// All loads every page left and returns the whole list
+func (p *Pager[T]) All(ctx context.Context) ([]T, error) {
+	for p.more {
+		if err := p.Next(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return p.items, nil
+}
+
+// Items is what has been loaded so far
+func (p *Pager[T]) Items() []T {
+	return p.items
+}
+
+// Pages is the number of pages loaded
+func (p *Pager[T]) Pages() int {
+	return p.pages
+}
+
+// More reports whether there are pages left to load
+func (p *Pager[T]) More() bool {
+	return p.more
+}
+
+// Total is the length of the whole list, if known
+func (p *Pager[T]) Total() (int, bool) {
+	return p.total, p.total >= 0
+}
+
+// Status is "loaded 20 of 57", or "loaded 20 of ?" while the total is
+// unknown
+func (p *Pager[T]) Status() string {
+	if total, ok := p.Total(); ok {
+		return fmt.Sprintf("loaded %d of %d", len(p.items), total)
+	}
+	return fmt.Sprintf("loaded %d of ?", len(p.items))
+}
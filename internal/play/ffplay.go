@@ -0,0 +1,186 @@
+package play
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ffplayRun is one ffplay process, playing from a position
+type ffplayRun struct {
+	cmd    *exec.Cmd
+	done   chan struct{}
+	killed atomic.Bool // ended by Pause, Seek or Stop rather than by playing out
+}
+
+func (r *ffplayRun) kill() {
+	r.killed.Store(true)
+	r.cmd.Process.Kill()
+	<-r.done
+}
+
+// ffplayPlayer is the ffplay backend. ffplay cannot be controlled while
+// it runs, so pausing ends the process and playing starts a new one at
+// the position kept by the clock. It plays the stream as the server
+// sends it; the source's quality is not used.
+type ffplayPlayer struct {
+	events
+	clock    clock
+	terminal bool
+
+	mu      sync.Mutex
+	src     Source
+	loaded  bool
+	started bool
+	run     *ffplayRun
+}
+
+func newFFplayPlayer(o Options) *ffplayPlayer {
+	return &ffplayPlayer{events: newEvents(), clock: clock{speed: 1}, terminal: o.Terminal}
+}
+
+func (p *ffplayPlayer) Load(src Source) error {
+	p.Stop()
+	p.mu.Lock()
+	p.src, p.loaded, p.started = src, true, false
+	p.mu.Unlock()
+	p.clock.set(0, 0)
+	return nil
+}
+
+// start runs ffplay from the clock's position; p.mu is held
+func (p *ffplayPlayer) start() error {
+	args := []string{"-nodisp", "-autoexit", "-loglevel", "error",
+		"-ss", fmt.Sprintf("%.3f", p.clock.now()), "-i", p.src.URL}
+	r := &ffplayRun{cmd: exec.Command("ffplay", args...), done: make(chan struct{})}
+	if p.terminal {
+		r.cmd.Stdout = os.Stdout
+		r.cmd.Stderr = os.Stderr
+	}
+	if err := r.cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ffplay: %v", err)
+	}
+	p.run = r
+	p.clock.start()
+
+	go func() {
+		r.cmd.Wait()
+		close(r.done)
+		if !r.killed.Load() {
+			p.ended(r)
+		}
+	}()
+	go p.report(r)
+	return nil
+}
+
+// report sends the position while r runs
+func (p *ffplayPlayer) report(r *ffplayRun) {
+	ticker := time.NewTicker(PositionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+			p.emit(Event{Kind: Position, Position: p.clock.now(), Duration: p.src.Duration})
+		}
+	}
+}
+
+// ended handles ffplay exiting at the end of the track
+func (p *ffplayPlayer) ended(r *ffplayRun) {
+	p.mu.Lock()
+	if p.run != r {
+		p.mu.Unlock()
+		return
+	}
+	p.run, p.loaded = nil, false
+	p.clock.stop()
+	dur := p.src.Duration
+	p.mu.Unlock()
+	p.emit(Event{Kind: Ended, Position: dur, Duration: dur})
+}
+
+func (p *ffplayPlayer) Play() error {
+	p.mu.Lock()
+	if !p.loaded {
+		p.mu.Unlock()
+		return fmt.Errorf("nothing loaded")
+	}
+	if p.run != nil {
+		p.mu.Unlock()
+		return nil
+	}
+	if err := p.start(); err != nil {
+		p.mu.Unlock()
+		return err
+	}
+	kind := Resumed
+	if !p.started {
+		kind = Started
+	}
+	p.started = true
+	e := Event{Kind: kind, Position: p.clock.now(), Duration: p.src.Duration}
+	p.mu.Unlock()
+	p.emit(e)
+	return nil
+}
+
+func (p *ffplayPlayer) Pause() error {
+	p.mu.Lock()
+	r := p.run
+	p.run = nil
+	p.mu.Unlock()
+	if r == nil {
+		return nil
+	}
+	r.kill()
+	p.clock.stop()
+	p.emit(Event{Kind: Paused, Position: p.clock.now(), Duration: p.src.Duration})
+	return nil
+}
+
+func (p *ffplayPlayer) Seek(seconds float64, relative bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.loaded {
+		return fmt.Errorf("nothing loaded")
+	}
+	if relative {
+		seconds += p.clock.now()
+	}
+	r := p.run
+	p.run = nil
+	if r != nil {
+		r.kill()
+		p.clock.stop()
+	}
+	p.clock.set(seconds, p.src.Duration)
+	if r != nil {
+		return p.start()
+	}
+	return nil
+}
+
+func (p *ffplayPlayer) Stop() error {
+	p.mu.Lock()
+	r, loaded := p.run, p.loaded
+	p.run, p.loaded = nil, false
+	p.mu.Unlock()
+	if r != nil {
+		r.kill()
+	}
+	p.clock.stop()
+	if loaded {
+		p.emit(Event{Kind: Stopped, Position: p.clock.now(), Duration: p.src.Duration})
+	}
+	return nil
+}
+
+func (p *ffplayPlayer) Close() error {
+	return p.Stop()
+}
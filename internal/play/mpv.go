@@ -0,0 +1,313 @@
+package play
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/mpv"
+)
+
+// session is one track playing through ffmpeg → mpv, controlled over
+// mpv's JSON IPC socket
+type session struct {
+	ipc         *mpv.Client
+	ffmpeg, mpv *exec.Cmd
+	socket      string
+	done        chan struct{}
+	err         error // why ffmpeg failed, if it did; set before done closes
+
+	stopped atomic.Bool // ended by Stop rather than by playing out
+}
+
+var sessions atomic.Int64
+
+// startStream starts FFmpeg → MPV, paused. A terminal session shows
+// mpv's progress line and lets it take keys; otherwise both stay off
+// the terminal, e.g. under the TUI.
+func startStream(url, codec, format, bitrate string, terminal bool) (*session, error) {
+	args := []string{"-hide_banner", "-loglevel", "error", "-i", url}
+
+	if codec != "" {
+		args = append(args, "-c:a", codec)
+	}
+	if bitrate != "" {
+		args = append(args, "-b:a", bitrate)
+	}
+
+	args = append(args, "-f", format, "pipe:1")
+
+	s := &session{
+		socket: filepath.Join(os.TempDir(), fmt.Sprintf("dab-mpv-%d-%d.sock", os.Getpid(), sessions.Add(1))),
+		done:   make(chan struct{}),
+	}
+	mpvArgs := []string{"--input-ipc-server=" + s.socket, "--cache=yes", "--pause"}
+	if terminal {
+		mpvArgs = append(mpvArgs, "--term-playing-msg=${media-title} [${time-pos}/${duration}]")
+	} else {
+		mpvArgs = append(mpvArgs, "--no-terminal")
+	}
+	s.ffmpeg = exec.Command("ffmpeg", args...)
+	s.mpv = exec.Command("mpv", append(mpvArgs, "-")...)
+
+	// An OS pipe, so the children hold its ends themselves: when either
+	// exits the other sees EOF or EPIPE, with no copying goroutine of
+	// ours left blocked on the pipe
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipe: %v", err)
+	}
+	s.ffmpeg.Stdout = w
+	s.mpv.Stdin = r
+
+	// Off the terminal, ffmpeg's complaint goes into the error it fails with
+	var stderr bytes.Buffer
+	s.ffmpeg.Stderr = &stderr
+	if terminal {
+		s.ffmpeg.Stderr = os.Stderr
+		s.mpv.Stdout = os.Stdout
+		s.mpv.Stderr = os.Stderr
+	}
+
+	err = s.ffmpeg.Start()
+	w.Close()
+	if err != nil {
+		r.Close()
+		return nil, fmt.Errorf("failed to start ffmpeg: %v", err)
+	}
+	err = s.mpv.Start()
+	r.Close()
+	if err != nil {
+		s.ffmpeg.Process.Kill()
+		s.ffmpeg.Wait()
+		return nil, fmt.Errorf("failed to start mpv: %v", err)
+	}
+
+	go func() {
+		err := s.ffmpeg.Wait()
+		s.mpv.Wait()
+		// A broken pipe only means mpv quit first
+		if err != nil && !brokenPipe(err, stderr.String()) {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				err = fmt.Errorf("%v: %s", err, msg)
+			}
+			s.err = fmt.Errorf("ffmpeg failed: %v", err)
+		}
+		os.Remove(s.socket)
+		close(s.done)
+	}()
+
+	ipc, err := mpv.Dial(s.socket, 5*time.Second)
+	if err != nil {
+		s.stop()
+		return nil, err
+	}
+	s.ipc = ipc
+	return s, nil
+}
+
+// brokenPipe reports whether ffmpeg died writing to a pipe nobody reads
+func brokenPipe(err error, stderr string) bool {
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		if ws, ok := exit.Sys().(syscall.WaitStatus); ok && ws.Signaled() && ws.Signal() == syscall.SIGPIPE {
+			return true
+		}
+	}
+	return strings.Contains(stderr, "Broken pipe")
+}
+
+// stop ends playback, asking mpv to quit before killing it
+func (s *session) stop() {
+	s.stopped.Store(true)
+	if s.ipc != nil {
+		s.ipc.Quit()
+	}
+	select {
+	case <-s.done:
+	case <-time.After(2 * time.Second):
+		s.mpv.Process.Kill()
+		s.ffmpeg.Process.Kill()
+		<-s.done
+	}
+	if s.ipc != nil {
+		s.ipc.Close()
+	}
+}
+
+// mpvPlayer is the mpv backend
+type mpvPlayer struct {
+	events
+	terminal bool
+
+	mu      sync.Mutex
+	s       *session
+	src     Source
+	started bool
+	paused  bool
+}
+
+func newMPVPlayer(o Options) *mpvPlayer {
+	return &mpvPlayer{events: newEvents(), terminal: o.Terminal}
+}
+
+func (p *mpvPlayer) Load(src Source) error {
+	p.Stop()
+	codec, format, bitrate := mapQualityToFFmpegFlags(src.Quality)
+	s, err := startStream(src.URL, codec, format, bitrate, p.terminal)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.s, p.src, p.started, p.paused = s, src, false, true
+	p.mu.Unlock()
+	go p.watch(s, src)
+	return nil
+}
+
+// watch reports the position of a session until it ends
+func (p *mpvPlayer) watch(s *session, src Source) {
+	ticker := time.NewTicker(PositionInterval)
+	defer ticker.Stop()
+	var pos, dur float64 = 0, src.Duration
+	for {
+		select {
+		case <-s.done:
+			p.mu.Lock()
+			if p.s == s {
+				p.s = nil
+			}
+			p.mu.Unlock()
+			// Killing ffmpeg is how a stop ends it, so its error only
+			// counts for a session that ended by itself
+			switch {
+			case s.stopped.Load():
+				p.emit(Event{Kind: Stopped, Position: pos, Duration: dur})
+			case s.err != nil:
+				p.emit(Event{Kind: Failed, Position: pos, Duration: dur, Err: s.err})
+			default:
+				p.emit(Event{Kind: Ended, Position: dur, Duration: dur})
+			}
+			return
+		case <-ticker.C:
+			if paused, err := s.ipc.Paused(); err != nil || paused {
+				continue
+			}
+			if v, err := s.ipc.Position(); err == nil {
+				pos = v
+			}
+			if v, err := s.ipc.Duration(); err == nil && v > 0 {
+				dur = v
+			}
+			p.emit(Event{Kind: Position, Position: pos, Duration: dur})
+		}
+	}
+}
+
+// current returns the loaded session, or an error if there is none
+func (p *mpvPlayer) current() (*session, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.s == nil {
+		return nil, fmt.Errorf("nothing loaded")
+	}
+	return p.s, nil
+}
+
+func (p *mpvPlayer) Play() error {
+	s, err := p.current()
+	if err != nil {
+		return err
+	}
+	if err := s.ipc.Resume(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	kind := Resumed
+	if !p.started {
+		kind = Started
+	}
+	p.started, p.paused = true, false
+	dur := p.src.Duration
+	p.mu.Unlock()
+	p.emit(Event{Kind: kind, Duration: dur})
+	return nil
+}
+
+func (p *mpvPlayer) Pause() error {
+	s, err := p.current()
+	if err != nil {
+		return err
+	}
+	if err := s.ipc.Pause(); err != nil {
+		return err
+	}
+	pos, _ := s.ipc.Position()
+	p.mu.Lock()
+	p.paused = true
+	dur := p.src.Duration
+	p.mu.Unlock()
+	p.emit(Event{Kind: Paused, Position: pos, Duration: dur})
+	return nil
+}
+
+func (p *mpvPlayer) Seek(seconds float64, relative bool) error {
+	s, err := p.current()
+	if err != nil {
+		return err
+	}
+	return s.ipc.Seek(seconds, relative)
+}
+
+func (p *mpvPlayer) Stop() error {
+	p.mu.Lock()
+	s := p.s
+	p.s = nil
+	p.mu.Unlock()
+	if s != nil {
+		s.stop()
+	}
+	return nil
+}
+
+func (p *mpvPlayer) AddVolume(delta float64) error {
+	s, err := p.current()
+	if err != nil {
+		return err
+	}
+	return s.ipc.AddVolume(delta)
+}
+
+func (p *mpvPlayer) ToggleMute() error {
+	s, err := p.current()
+	if err != nil {
+		return err
+	}
+	return s.ipc.ToggleMute()
+}
+
+func (p *mpvPlayer) Volume() (float64, bool, error) {
+	s, err := p.current()
+	if err != nil {
+		return 0, false, err
+	}
+	vol, err := s.ipc.Volume()
+	if err != nil {
+		return 0, false, err
+	}
+	muted, err := s.ipc.Muted()
+	return vol, muted, err
+}
+
+func (p *mpvPlayer) Close() error {
+	return p.Stop()
+}
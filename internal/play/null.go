@@ -0,0 +1,207 @@
+package play
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// nullPlayer is the null backend: it plays no audio but fetches the
+// stream, into Options.Output if set, and keeps time like a real player
+// would, so the whole playback flow runs without audio hardware or
+// external tools. Tracks of unknown length end once fetched.
+type nullPlayer struct {
+	events
+	clock  clock
+	output string
+
+	mu      sync.Mutex
+	src     Source
+	loaded  bool
+	started bool
+	fetched bool
+	cancel  context.CancelFunc // ends the fetch and the clock of the loaded track
+}
+
+func newNullPlayer(o Options) *nullPlayer {
+	speed := o.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+	return &nullPlayer{events: newEvents(), clock: clock{speed: speed}, output: o.Output}
+}
+
+func (p *nullPlayer) Load(src Source) error {
+	p.Stop()
+	p.mu.Lock()
+	p.src, p.loaded, p.started, p.fetched = src, true, false, false
+	p.mu.Unlock()
+	p.clock.set(0, 0)
+	return nil
+}
+
+func (p *nullPlayer) Play() error {
+	p.mu.Lock()
+	if !p.loaded {
+		p.mu.Unlock()
+		return fmt.Errorf("nothing loaded")
+	}
+	kind := Resumed
+	if !p.started {
+		kind = Started
+		ctx, cancel := context.WithCancel(context.Background())
+		p.started, p.cancel = true, cancel
+		go p.fetch(ctx, p.src.URL)
+		go p.tick(ctx)
+	}
+	p.clock.start()
+	e := Event{Kind: kind, Position: p.clock.now(), Duration: p.src.Duration}
+	p.mu.Unlock()
+	p.emit(e)
+	return nil
+}
+
+// fetch downloads the stream as a player would
+func (p *nullPlayer) fetch(ctx context.Context, url string) {
+	err := func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("stream returned %s", resp.Status)
+		}
+
+		var w io.Writer = io.Discard
+		if p.output != "" {
+			f, err := os.Create(p.output)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		_, err = io.Copy(w, resp.Body)
+		return err
+	}()
+
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return // stopped or replaced
+	}
+	if err != nil {
+		p.fail(ctx, fmt.Errorf("failed to fetch stream: %v", err))
+		return
+	}
+	p.mu.Lock()
+	p.fetched = true
+	p.mu.Unlock()
+}
+
+// tick sends the position and ends the track once its time is up
+func (p *nullPlayer) tick(ctx context.Context) {
+	ticker := time.NewTicker(PositionInterval / 5)
+	defer ticker.Stop()
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		p.mu.Lock()
+		if ctx.Err() != nil {
+			p.mu.Unlock()
+			return
+		}
+		pos, dur := p.clock.now(), p.src.Duration
+		if (dur > 0 && pos >= dur) || (dur == 0 && p.fetched) {
+			p.finish()
+			p.mu.Unlock()
+			if dur > 0 {
+				pos = dur
+			}
+			p.emit(Event{Kind: Ended, Position: pos, Duration: dur})
+			return
+		}
+		report := p.clock.ticking() && time.Since(last) >= PositionInterval
+		p.mu.Unlock()
+		if report {
+			last = time.Now()
+			p.emit(Event{Kind: Position, Position: pos, Duration: dur})
+		}
+	}
+}
+
+// fail ends the track of ctx with an error
+func (p *nullPlayer) fail(ctx context.Context, err error) {
+	p.mu.Lock()
+	if ctx.Err() != nil {
+		p.mu.Unlock()
+		return
+	}
+	p.finish()
+	p.mu.Unlock()
+	p.emit(Event{Kind: Failed, Err: err})
+}
+
+// finish unloads the track; p.mu is held
+func (p *nullPlayer) finish() {
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+	p.loaded = false
+	p.clock.stop()
+}
+
+func (p *nullPlayer) Pause() error {
+	p.mu.Lock()
+	if !p.started || !p.loaded {
+		p.mu.Unlock()
+		return nil
+	}
+	p.clock.stop()
+	e := Event{Kind: Paused, Position: p.clock.now(), Duration: p.src.Duration}
+	p.mu.Unlock()
+	p.emit(e)
+	return nil
+}
+
+func (p *nullPlayer) Seek(seconds float64, relative bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.loaded {
+		return fmt.Errorf("nothing loaded")
+	}
+	if relative {
+		seconds += p.clock.now()
+	}
+	p.clock.set(seconds, p.src.Duration)
+	return nil
+}
+
+func (p *nullPlayer) Stop() error {
+	p.mu.Lock()
+	loaded := p.loaded
+	p.finish()
+	e := Event{Kind: Stopped, Position: p.clock.now(), Duration: p.src.Duration}
+	p.mu.Unlock()
+	if loaded {
+		p.emit(e)
+	}
+	return nil
+}
+
+func (p *nullPlayer) Close() error {
+	return p.Stop()
+}
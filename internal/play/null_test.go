@@ -0,0 +1,97 @@
+package play_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/mock/mocktest"
+	"github.com/adityadeshmukh1/dab-cli/internal/play"
+)
+
+// next returns p's next event, failing if none comes in time
+func next(t *testing.T, p play.Player) play.Event {
+	t.Helper()
+	select {
+	case e := <-p.Events():
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event from the player")
+	}
+	return play.Event{}
+}
+
+// start loads and plays a track on a fresh null player
+func start(t *testing.T, o play.Options, duration int) play.Player {
+	t.Helper()
+	o.Backend = play.BackendNull
+	p, err := play.New(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close() })
+	src, err := play.TrackSource("t1", duration, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Load(src); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Play(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestNullPlaysToTheEnd(t *testing.T) {
+	mocktest.Start(t)
+	out := filepath.Join(t.TempDir(), "stream")
+
+	// 10 seconds at 10x take one second, for a position or two
+	p := start(t, play.Options{Speed: 10, Output: out}, 10)
+	if e := next(t, p); e.Kind != play.Started || e.Duration != 10 {
+		t.Fatalf("first event %+v, want started", e)
+	}
+	positions, last := 0, 0.0
+	for {
+		e := next(t, p)
+		if e.Kind == play.Ended {
+			if e.Position != 10 {
+				t.Errorf("ended at %v, want 10", e.Position)
+			}
+			break
+		}
+		if e.Kind != play.Position || e.Position < last {
+			t.Fatalf("got %+v after position %v, want positions until the end", e, last)
+		}
+		positions++
+		last = e.Position
+	}
+	if positions == 0 {
+		t.Error("no position between start and end")
+	}
+	if info, err := os.Stat(out); err != nil || info.Size() == 0 {
+		t.Errorf("stream not fetched into the output: %v", err)
+	}
+}
+
+func TestNullStopIsNotAnEnd(t *testing.T) {
+	mocktest.Start(t)
+
+	p := start(t, play.Options{Speed: 1}, 95)
+	if e := next(t, p); e.Kind != play.Started {
+		t.Fatalf("first event %+v, want started", e)
+	}
+	if err := p.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if e := next(t, p); e.Kind != play.Stopped {
+		t.Fatalf("after Stop got %+v, want stopped", e)
+	}
+	select {
+	case e := <-p.Events():
+		t.Errorf("event %+v after the stop", e)
+	case <-time.After(2 * play.PositionInterval):
+	}
+}
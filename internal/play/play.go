@@ -1,84 +1,37 @@
 package play
 
 import (
-	"fmt"
-	"io"
-	"os"
-	"os/exec"
-
-	"github.com/adityadeshmukh1/dab-cli/internal/store"
+	"github.com/adityadeshmukh1/dab-cli/internal/transcode"
 )
 
-// Map quality to FFmpeg flags
+// Map quality to FFmpeg flags. Qualities are transcode profiles, so
+// low/medium/high/flac and any user-defined profile work for playback.
 func mapQualityToFFmpegFlags(q string) (codec, format, bitrate string) {
-	switch q {
-	case "low":
-		return "libmp3lame", "mp3", "96k"
-	case "medium":
-		return "libmp3lame", "mp3", "160k"
-	case "high":
-		return "libmp3lame", "mp3", "256k"
-	case "flac":
-		return "flac", "flac", ""
-	default:
-		return "libmp3lame", "mp3", "192k"
+	p, err := transcode.Lookup(q)
+	if err != nil {
+		p = transcode.Fallback
 	}
+	return p.Codec, p.Format, p.Bitrate
 }
 
-// Play stream via FFmpeg → MPV with minimal progress output
-func playStream(url, codec, format, bitrate string) error {
-	args := []string{"-hide_banner", "-loglevel", "error", "-i", url}
-
-	if codec != "" {
-		args = append(args, "-c:a", codec)
-	}
-	if bitrate != "" {
-		args = append(args, "-b:a", bitrate)
-	}
-
-	args = append(args, "-f", format, "pipe:1")
-
-	ffmpeg := exec.Command("ffmpeg", args...)
-	mpv := exec.Command("mpv", "--term-playing-msg=${media-title} [${time-pos}/${duration}]", "-")
-
-	r, w := io.Pipe()
-	ffmpeg.Stdout = w
-	mpv.Stdin = r
-
-	ffmpeg.Stderr = os.Stderr
-	mpv.Stdout = os.Stdout
-	mpv.Stderr = os.Stderr
-
-	if err := ffmpeg.Start(); err != nil {
-		return fmt.Errorf("failed to start ffmpeg: %v", err)
-	}
-	if err := mpv.Start(); err != nil {
-		return fmt.Errorf("failed to start mpv: %v", err)
+// PlayTrackWith streams a track with a player of the given options and
+// returns once it has played
+func PlayTrackWith(o Options, trackID string) error {
+	p, err := New(o)
+	if err != nil {
+		return err
 	}
+	defer p.Close()
 
-	ffmpeg.Wait()
-	w.Close()
-	mpv.Wait()
-	return nil
-}
-
-func Play(trackNumber int, quality string) error {
-	// Load the last search map
-	if err := store.LoadFromFile(".dabcli_last_search.json"); err != nil {
-		return fmt.Errorf("could not load last search results: %v", err)
+	src, err := TrackSource(trackID, 0, o.Quality)
+	if err != nil {
+		return err
 	}
-
-	trackID, ok := store.GetSongID(trackNumber)
-	if !ok {
-		return fmt.Errorf("track number %d not found in last search", trackNumber)
+	if err := p.Load(src); err != nil {
+		return err
 	}
-
-	codec, format, bitrate := mapQualityToFFmpegFlags(quality)
-
-	url, err := store.FetchStreamURL(trackID)
-	if err != nil {
-		return fmt.Errorf("failed to fetch stream URL: %v", err)
+	if err := p.Play(); err != nil {
+		return err
 	}
-
-	return playStream(url, codec, format, bitrate)
+	return Wait(p, nil)
 }
@@ -0,0 +1,219 @@
+package play
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/config"
+	"github.com/adityadeshmukh1/dab-cli/internal/store"
+)
+
+// Event kinds
+const (
+	Started  = "started"  // a loaded track started playing
+	Position = "position" // sent every PositionInterval while playing
+	Paused   = "paused"
+	Resumed  = "resumed"
+	Ended    = "ended"   // the track played to the end
+	Stopped  = "stopped" // Stop, or loading another track, ended it early
+	Failed   = "error"
+)
+
+// PositionInterval is how often players report the position
+const PositionInterval = 500 * time.Millisecond
+
+// Event reports a change in playback
+type Event struct {
+	Kind     string
+	Position float64 // seconds
+	Duration float64 // seconds, 0 if unknown
+	Err      error   // set for Failed
+}
+
+// Source is a track for a player to load
+type Source struct {
+	URL      string
+	Duration float64 // seconds, from the track's metadata; 0 if unknown
+	Quality  string  // transcode profile, for backends that transcode
+}
+
+// Player plays one track at a time. Load replaces the current track,
+// ready to Play. Events must be read, or the player stalls on the next
+// Started, Ended, Stopped or Failed event; Position events are dropped
+// while nobody reads them.
+type Player interface {
+	Load(src Source) error
+	Play() error
+	Pause() error
+	Seek(seconds float64, relative bool) error
+	Stop() error
+	Events() <-chan Event
+	Close() error
+}
+
+// Mixer is implemented by players that can change the volume
+type Mixer interface {
+	AddVolume(delta float64) error
+	ToggleMute() error
+	Volume() (percent float64, muted bool, err error)
+}
+
+// Backends
+const (
+	BackendMPV    = "mpv"    // ffmpeg → mpv, controlled over mpv's IPC socket
+	BackendFFplay = "ffplay" // ffplay, restarted at the position to pause and seek
+	BackendNull   = "null"   // no audio: fetches the stream and keeps time
+)
+
+// Backends lists the player backends
+var Backends = []string{BackendMPV, BackendFFplay, BackendNull}
+
+// DefaultQuality is the transcode profile tracks play through unless
+// the config file or a flag picks another
+const DefaultQuality = "medium"
+
+// Options choose and configure a player backend
+type Options struct {
+	Backend  string
+	Terminal bool    // let the player use the terminal, e.g. mpv's status line and keys
+	Output   string  // null: file the fetched stream is written to, if any
+	Speed    float64 // null: how much faster than real time the clock runs
+	Quality  string  // transcode profile, e.g. low, medium, high or flac
+}
+
+// Configured returns the player options from the config file
+func Configured() Options {
+	p := config.Active().Player
+	o := Options{Backend: p.Backend, Output: p.Output, Speed: p.Speed, Quality: p.Quality}
+	if o.Backend == "" {
+		o.Backend = BackendMPV
+	}
+	if o.Quality == "" {
+		o.Quality = DefaultQuality
+	}
+	return o
+}
+
+// New creates a player for the chosen backend
+func New(o Options) (Player, error) {
+	switch o.Backend {
+	case BackendMPV, "":
+		return newMPVPlayer(o), nil
+	case BackendFFplay:
+		return newFFplayPlayer(o), nil
+	case BackendNull:
+		return newNullPlayer(o), nil
+	}
+	return nil, fmt.Errorf("unknown player backend %q (want %s)", o.Backend, strings.Join(Backends, ", "))
+}
+
+// TrackSource fetches the stream URL of a track for a player.
+// duration is the track's length in seconds, if known.
+func TrackSource(trackID string, duration int, quality string) (Source, error) {
+	url, err := store.FetchStreamURL(trackID)
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to fetch stream URL: %w", err)
+	}
+	return Source{URL: url, Duration: float64(duration), Quality: quality}, nil
+}
+
+// Wait plays what p has loaded until it ends or stops, passing every
+// event to watch if not nil
+func Wait(p Player, watch func(Event)) error {
+	for e := range p.Events() {
+		if watch != nil {
+			watch(e)
+		}
+		switch e.Kind {
+		case Ended, Stopped:
+			return nil
+		case Failed:
+			return e.Err
+		}
+	}
+	return nil
+}
+
+// events is the event channel shared by the backends
+type events struct {
+	ch chan Event
+}
+
+func newEvents() events {
+	return events{ch: make(chan Event, 16)}
+}
+
+func (e events) Events() <-chan Event {
+	return e.ch
+}
+
+// emit sends an event; position updates are dropped rather than block
+func (e events) emit(ev Event) {
+	if ev.Kind == Position {
+		select {
+		case e.ch <- ev:
+		default:
+		}
+		return
+	}
+	e.ch <- ev
+}
+
+// clock keeps the position of backends that cannot ask their output
+type clock struct {
+	mu      sync.Mutex
+	pos     float64 // at since, or now if stopped
+	since   time.Time
+	running bool
+	speed   float64
+}
+
+func (c *clock) now() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.nowLocked()
+}
+
+func (c *clock) nowLocked() float64 {
+	if !c.running {
+		return c.pos
+	}
+	return c.pos + time.Since(c.since).Seconds()*c.speed
+}
+
+// ticking reports whether the clock runs
+func (c *clock) ticking() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.running
+}
+
+func (c *clock) start() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running {
+		c.since, c.running = time.Now(), true
+	}
+}
+
+func (c *clock) stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pos, c.running = c.nowLocked(), false
+}
+
+// set moves the clock, to at most max seconds if max is known
+func (c *clock) set(seconds, max float64) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if max > 0 && seconds > max {
+		seconds = max
+	}
+	if seconds < 0 {
+		seconds = 0
+	}
+	c.pos, c.since = seconds, time.Now()
+	return seconds
+}
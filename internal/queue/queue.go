@@ -0,0 +1,385 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/config"
+)
+
+// File is where the local queue is kept between sessions. Each server
+// has its own, since its tracks are only good there.
+func File() string {
+	return config.ServerFile(".dabcli_queue.json")
+}
+
+// Repeat modes
+const (
+	RepeatOff = "off"
+	RepeatOne = "one"
+	RepeatAll = "all"
+)
+
+// RepeatModes lists the repeat modes in the order they are cycled
+var RepeatModes = []string{RepeatOff, RepeatAll, RepeatOne}
+
+// state is what is saved to disk
+type state struct {
+	Tracks []api.Track `json:"tracks"`
+	Pos    int         `json:"pos"`
+	Repeat string      `json:"repeat"`
+
+	// Sync bookkeeping: the track IDs the server held at the last sync,
+	// when it was last seen holding them, and the local edits since
+	Synced     []string  `json:"synced,omitempty"`
+	RemoteSeen time.Time `json:"remoteSeen"`
+	Journal    []Edit    `json:"journal,omitempty"`
+}
+
+// Edit is a journaled local change to the track list
+type Edit struct {
+	Time   time.Time `json:"time"`
+	Op     string    `json:"op"`
+	Tracks int       `json:"tracks"` // queue length afterwards
+}
+
+// maxJournal bounds the journal; only its newest entry decides conflicts
+const maxJournal = 50
+
+// Queue is the local playback queue. Pos is the track playing or about
+// to play; it equals len(Tracks) once the queue has played through, so
+// tracks added afterwards play next. Every change is saved to disk.
+type Queue struct {
+	mu     sync.Mutex
+	path   string
+	s      state
+	onEdit func()
+}
+
+// Load reads the queue saved in File
+func Load() (*Queue, error) {
+	return Open(File())
+}
+
+// Open reads a queue saved at path; a missing file is an empty queue
+func Open(path string) (*Queue, error) {
+	q := &Queue{path: path, s: state{Repeat: RepeatOff}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queue: %v", err)
+	}
+	if err := json.Unmarshal(data, &q.s); err != nil {
+		return nil, fmt.Errorf("failed to parse queue %s: %v", path, err)
+	}
+	if q.s.Pos < 0 || q.s.Pos > len(q.s.Tracks) {
+		q.s.Pos = 0
+	}
+	if !validRepeat(q.s.Repeat) {
+		q.s.Repeat = RepeatOff
+	}
+	return q, nil
+}
+
+func validRepeat(mode string) bool {
+	for _, m := range RepeatModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// OnEdit registers f to run after every change to the track list. It
+// runs with the queue locked, so it must not call back into the queue.
+func (q *Queue) OnEdit(f func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.onEdit = f
+}
+
+// edit journals a change to the track list and saves it; the caller
+// holds the lock. Moving through the queue and the repeat mode are
+// local only and use save.
+func (q *Queue) edit(op string) error {
+	q.s.Journal = append(q.s.Journal, Edit{Time: time.Now(), Op: op, Tracks: len(q.s.Tracks)})
+	if n := len(q.s.Journal); n > maxJournal {
+		q.s.Journal = q.s.Journal[n-maxJournal:]
+	}
+	if err := q.save(); err != nil {
+		return err
+	}
+	if q.onEdit != nil {
+		q.onEdit()
+	}
+	return nil
+}
+
+// save writes the queue; the caller holds the lock
+func (q *Queue) save() error {
+	data, err := json.MarshalIndent(q.s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal queue: %v", err)
+	}
+	if err := os.WriteFile(q.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to save queue: %v", err)
+	}
+	return nil
+}
+
+// Tracks returns a copy of the queued tracks
+func (q *Queue) Tracks() []api.Track {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]api.Track(nil), q.s.Tracks...)
+}
+
+// Len is the number of queued tracks
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.s.Tracks)
+}
+
+// Current returns the track at the play position and its index; ok is
+// false when the queue is empty or has played through
+func (q *Queue) Current() (t api.Track, index int, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.s.Pos >= len(q.s.Tracks) {
+		return api.Track{}, q.s.Pos, false
+	}
+	return q.s.Tracks[q.s.Pos], q.s.Pos, true
+}
+
+// Repeat returns the repeat mode
+func (q *Queue) Repeat() string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.s.Repeat
+}
+
+// SetRepeat sets the repeat mode: RepeatOff, RepeatOne or RepeatAll
+func (q *Queue) SetRepeat(mode string) error {
+	if !validRepeat(mode) {
+		return fmt.Errorf("unknown repeat mode %q (want off, one or all)", mode)
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.s.Repeat = mode
+	return q.save()
+}
+
+// CycleRepeat steps to the next repeat mode and returns it
+func (q *Queue) CycleRepeat() (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, m := range RepeatModes {
+		if m == q.s.Repeat {
+			q.s.Repeat = RepeatModes[(i+1)%len(RepeatModes)]
+			break
+		}
+	}
+	return q.s.Repeat, q.save()
+}
+
+// Enqueue adds tracks to the end of the queue
+func (q *Queue) Enqueue(tracks ...api.Track) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.s.Tracks = append(q.s.Tracks, tracks...)
+	return q.edit("enqueue")
+}
+
+// InsertNext adds tracks right after the current one
+func (q *Queue) InsertNext(tracks ...api.Track) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	at := q.s.Pos + 1
+	if at > len(q.s.Tracks) {
+		at = len(q.s.Tracks)
+	}
+	q.s.Tracks = append(q.s.Tracks[:at], append(append([]api.Track(nil), tracks...), q.s.Tracks[at:]...)...)
+	return q.edit("insert-next")
+}
+
+// Remove drops the track at index; removing the current track makes
+// the one after it current
+func (q *Queue) Remove(index int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.check(index); err != nil {
+		return err
+	}
+	q.s.Tracks = append(q.s.Tracks[:index], q.s.Tracks[index+1:]...)
+	if index < q.s.Pos {
+		q.s.Pos--
+	}
+	return q.edit("remove")
+}
+
+// Move puts the track at from at index to, keeping the current track
+// current
+func (q *Queue) Move(from, to int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.check(from); err != nil {
+		return err
+	}
+	if err := q.check(to); err != nil {
+		return err
+	}
+	t := q.s.Tracks[from]
+	q.s.Tracks = append(q.s.Tracks[:from], q.s.Tracks[from+1:]...)
+	q.s.Tracks = append(q.s.Tracks[:to], append([]api.Track{t}, q.s.Tracks[to:]...)...)
+
+	switch {
+	case q.s.Pos == from:
+		q.s.Pos = to
+	case from < q.s.Pos && to >= q.s.Pos:
+		q.s.Pos--
+	case from > q.s.Pos && to <= q.s.Pos:
+		q.s.Pos++
+	}
+	return q.edit("move")
+}
+
+// Clear empties the queue
+func (q *Queue) Clear() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.s.Tracks, q.s.Pos = nil, 0
+	return q.edit("clear")
+}
+
+// Replace swaps in a new list of tracks and starts it from the top
+func (q *Queue) Replace(tracks []api.Track) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.s.Tracks, q.s.Pos = append([]api.Track(nil), tracks...), 0
+	return q.edit("replace")
+}
+
+// Shuffle reorders the tracks after the current one. A queue that has
+// played through is shuffled whole and starts over.
+func (q *Queue) Shuffle() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	start := q.s.Pos + 1
+	if q.s.Pos >= len(q.s.Tracks) {
+		start, q.s.Pos = 0, 0
+	}
+	rest := q.s.Tracks[start:]
+	rand.Shuffle(len(rest), func(i, j int) { rest[i], rest[j] = rest[j], rest[i] })
+	return q.edit("shuffle")
+}
+
+// Jump makes the track at index current
+func (q *Queue) Jump(index int) (api.Track, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.check(index); err != nil {
+		return api.Track{}, err
+	}
+	q.s.Pos = index
+	return q.s.Tracks[index], q.save()
+}
+
+// Next skips to the following track, wrapping around with RepeatAll.
+// ok is false when the queue has played through.
+func (q *Queue) Next() (t api.Track, ok bool, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.advance(false)
+}
+
+// Ended moves on after the current track finished by itself: the same
+// track again with RepeatOne, otherwise as Next
+func (q *Queue) Ended() (t api.Track, ok bool, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.advance(true)
+}
+
+func (q *Queue) advance(ended bool) (api.Track, bool, error) {
+	n := len(q.s.Tracks)
+	switch {
+	case n == 0:
+		q.s.Pos = 0
+	case ended && q.s.Repeat == RepeatOne && q.s.Pos < n:
+		// play it again
+	case q.s.Pos+1 < n:
+		q.s.Pos++
+	case q.s.Repeat == RepeatAll:
+		q.s.Pos = 0
+	default:
+		q.s.Pos = n
+	}
+	if err := q.save(); err != nil {
+		return api.Track{}, false, err
+	}
+	if q.s.Pos >= n {
+		return api.Track{}, false, nil
+	}
+	return q.s.Tracks[q.s.Pos], true, nil
+}
+
+// Previous steps back a track, wrapping around with RepeatAll
+func (q *Queue) Previous() (t api.Track, ok bool, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := len(q.s.Tracks)
+	if n == 0 {
+		return api.Track{}, false, nil
+	}
+	switch {
+	case q.s.Pos > 0:
+		q.s.Pos--
+	case q.s.Repeat == RepeatAll:
+		q.s.Pos = n - 1
+	}
+	if q.s.Pos >= n {
+		q.s.Pos = n - 1
+	}
+	return q.s.Tracks[q.s.Pos], true, q.save()
+}
+
+// Play plays from the current track on, advancing as each one ends,
+// until the queue plays through or play fails. A queue that already
+// played through starts over.
+func (q *Queue) Play(play func(api.Track) error) error {
+	t, _, ok := q.Current()
+	if !ok {
+		if q.Len() == 0 {
+			return fmt.Errorf("the queue is empty")
+		}
+		var err error
+		if t, err = q.Jump(0); err != nil {
+			return err
+		}
+	}
+	for {
+		if err := play(t); err != nil {
+			return err
+		}
+		next, ok, err := q.Ended()
+		if err != nil || !ok {
+			return err
+		}
+		t = next
+	}
+}
+
+func (q *Queue) check(index int) error {
+	if index < 0 || index >= len(q.s.Tracks) {
+		return fmt.Errorf("no track %d in the queue", index+1)
+	}
+	return nil
+}
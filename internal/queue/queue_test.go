@@ -0,0 +1,203 @@
+package queue
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/config"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+)
+
+func track(id string) api.Track {
+	return api.Track{Id: models.Ptr(id), Title: models.Ptr("Track " + id)}
+}
+
+func tracks(ids ...string) []api.Track {
+	var out []api.Track
+	for _, id := range ids {
+		out = append(out, track(id))
+	}
+	return out
+}
+
+func idsOf(tracks []api.Track) string {
+	var ids []string
+	for _, t := range tracks {
+		ids = append(ids, models.Value(t.Id))
+	}
+	return strings.Join(ids, " ")
+}
+
+func open(t *testing.T) *Queue {
+	t.Helper()
+	q, err := Open(filepath.Join(t.TempDir(), File()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+// check fails unless q holds ids with the track at pos current
+func check(t *testing.T, q *Queue, ids string, pos int) {
+	t.Helper()
+	_, at, _ := q.Current()
+	if got := idsOf(q.Tracks()); got != ids || at != pos {
+		t.Fatalf("queue is %q at %d, want %q at %d", got, at, ids, pos)
+	}
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEditsKeepTheCurrentTrack(t *testing.T) {
+	q := open(t)
+	must(t, q.Enqueue(tracks("a", "b", "c", "d")...))
+	_, err := q.Jump(1)
+	must(t, err)
+	check(t, q, "a b c d", 1)
+
+	must(t, q.InsertNext(tracks("x", "y")...))
+	check(t, q, "a b x y c d", 1)
+
+	must(t, q.Remove(0))
+	check(t, q, "b x y c d", 0)
+
+	must(t, q.Move(0, 3)) // the current track itself
+	check(t, q, "x y c b d", 3)
+	must(t, q.Move(4, 0)) // from after to before it
+	check(t, q, "d x y c b", 4)
+	must(t, q.Move(0, 4)) // from before to after it
+	check(t, q, "x y c b d", 3)
+
+	// Removing the current track makes the next one current
+	must(t, q.Remove(3))
+	check(t, q, "x y c d", 3)
+
+	if err := q.Remove(9); err == nil {
+		t.Error("removed a track past the end")
+	}
+	if err := q.Move(0, -1); err == nil {
+		t.Error("moved a track before the start")
+	}
+}
+
+func TestAdvanceAndRepeat(t *testing.T) {
+	q := open(t)
+	must(t, q.Enqueue(tracks("a", "b")...))
+
+	next := func(ended bool) string {
+		t.Helper()
+		var tr api.Track
+		var ok bool
+		var err error
+		if ended {
+			tr, ok, err = q.Ended()
+		} else {
+			tr, ok, err = q.Next()
+		}
+		must(t, err)
+		if !ok {
+			return ""
+		}
+		return models.Value(tr.Id)
+	}
+
+	if got := next(true); got != "b" {
+		t.Errorf("after a: %q", got)
+	}
+	if got := next(true); got != "" {
+		t.Errorf("played through, yet got %q", got)
+	}
+	// Added after playing through: plays next
+	must(t, q.Enqueue(track("c")))
+	if tr, _, ok := q.Current(); !ok || models.Value(tr.Id) != "c" {
+		t.Errorf("current %v, want c", tr.Id)
+	}
+
+	must(t, q.SetRepeat(RepeatOne))
+	if got := next(true); got != "c" {
+		t.Errorf("repeat one ended: %q, want c again", got)
+	}
+	if got := next(false); got != "" {
+		t.Errorf("repeat one skipped past the end: %q", got)
+	}
+
+	must(t, q.SetRepeat(RepeatAll))
+	if got := next(false); got != "a" {
+		t.Errorf("repeat all wrapped to %q, want a", got)
+	}
+	if tr, ok, err := q.Previous(); err != nil || !ok || models.Value(tr.Id) != "c" {
+		t.Errorf("previous from the first wrapped to %v, want c", tr.Id)
+	}
+	if err := q.SetRepeat("sometimes"); err == nil {
+		t.Error("accepted an unknown repeat mode")
+	}
+}
+
+func TestSavedBetweenSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), File())
+	q, err := Open(path)
+	must(t, err)
+	must(t, q.Enqueue(tracks("a", "b", "c")...))
+	_, err = q.Jump(2)
+	must(t, err)
+	must(t, q.SetRepeat(RepeatAll))
+
+	q, err = Open(path)
+	must(t, err)
+	check(t, q, "a b c", 2)
+	if q.Repeat() != RepeatAll || !q.Pending() {
+		t.Errorf("reopened with repeat %q, pending %v", q.Repeat(), q.Pending())
+	}
+}
+
+func TestShuffleKeepsThePlayedPart(t *testing.T) {
+	q := open(t)
+	must(t, q.Enqueue(tracks("a", "b", "c", "d", "e", "f")...))
+	_, err := q.Jump(1)
+	must(t, err)
+	must(t, q.Shuffle())
+
+	got := idsOf(q.Tracks())
+	if !strings.HasPrefix(got, "a b ") || len(got) != len("a b c d e f") {
+		t.Errorf("shuffled to %q", got)
+	}
+	for _, id := range []string{"c", "d", "e", "f"} {
+		if !strings.Contains(got, id) {
+			t.Errorf("%s lost in %q", id, got)
+		}
+	}
+}
+
+func TestEachServerHasItsQueue(t *testing.T) {
+	t.Chdir(t.TempDir())
+	cfg := "profiles:\n  prod: {base_url: http://a.test/api}\n  beta: {base_url: http://b.test/api}\n"
+	if err := os.WriteFile("config.yaml", []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	use := func(profile string) *Queue {
+		t.Helper()
+		must(t, config.Init(config.Overrides{ConfigPath: "config.yaml", Profile: profile}))
+		q, err := Load()
+		must(t, err)
+		return q
+	}
+
+	must(t, use("prod").Enqueue(track("a")))
+	must(t, use("beta").Enqueue(track("b")))
+	if File() != ".dabcli_queue-beta.json" {
+		t.Errorf("beta keeps its queue in %s", File())
+	}
+	check(t, use("prod"), "a", 0)
+	if File() != ".dabcli_queue.json" {
+		t.Errorf("the default profile keeps its queue in %s", File())
+	}
+	check(t, use("beta"), "b", 0)
+}
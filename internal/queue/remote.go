@@ -0,0 +1,67 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/client"
+)
+
+// FetchRemote returns the queue stored on the server
+func FetchRemote() ([]api.Track, error) {
+	c, err := client.New()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.GetQueueWithResponse(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("queue request failed: %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, client.ResponseError("queue", resp.StatusCode(), resp.Body)
+	}
+	if resp.JSON200 == nil || resp.JSON200.Queue == nil {
+		return nil, nil
+	}
+	return *resp.JSON200.Queue, nil
+}
+
+// PushRemote replaces the server's queue
+func PushRemote(tracks []api.Track) error {
+	c, err := client.New()
+	if err != nil {
+		return err
+	}
+
+	if tracks == nil {
+		tracks = []api.Track{}
+	}
+	resp, err := c.PostQueueWithResponse(context.Background(), api.PostQueueJSONRequestBody{Queue: tracks})
+	if err != nil {
+		return fmt.Errorf("save queue request failed: %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return client.ResponseError("save queue", resp.StatusCode(), resp.Body)
+	}
+	return nil
+}
+
+// DeleteRemote clears the server's queue
+func DeleteRemote() error {
+	c, err := client.New()
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.DeleteQueueWithResponse(context.Background())
+	if err != nil {
+		return fmt.Errorf("clear queue request failed: %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return client.ResponseError("clear queue", resp.StatusCode(), resp.Body)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package queue
+
+import (
+	"sync"
+	"time"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+)
+
+// Sync outcomes
+const (
+	Unchanged = "unchanged"
+	Pulled    = "pulled"
+	Pushed    = "pushed"
+)
+
+// SyncResult says what a sync did
+type SyncResult struct {
+	Action   string
+	Conflict bool // both sides had changed; Action says which won
+}
+
+// Sync reconciles the local queue with the server's. Whichever side
+// changed since the last sync is copied to the other. When both did,
+// the last writer wins: local edits are dated by the journal, and the
+// server's change by when it was last seen unchanged, the earliest it
+// can have happened.
+func (q *Queue) Sync() (SyncResult, error) {
+	remote, err := FetchRemote()
+	if err != nil {
+		return SyncResult{}, err
+	}
+
+	q.mu.Lock()
+	remoteChanged := !sameIDs(remote, q.s.Synced)
+	localChanged := len(q.s.Journal) > 0
+	if q.s.RemoteSeen.IsZero() && len(q.s.Tracks) > 0 {
+		// Never synced: a queue from before syncing counts as an edit
+		localChanged = true
+	}
+
+	var res SyncResult
+	switch {
+	case !remoteChanged && !localChanged:
+		q.s.RemoteSeen = time.Now()
+		err := q.save()
+		q.mu.Unlock()
+		return SyncResult{Action: Unchanged}, err
+	case remoteChanged && localChanged:
+		res.Conflict = true
+		res.Action = Pulled
+		if q.lastEdit().After(q.s.RemoteSeen) {
+			res.Action = Pushed
+		}
+	case remoteChanged:
+		res.Action = Pulled
+	default:
+		res.Action = Pushed
+	}
+
+	if res.Action == Pulled {
+		defer q.mu.Unlock()
+		return res, q.adopt(remote)
+	}
+	q.mu.Unlock()
+	return res, q.Push()
+}
+
+// Pending reports whether there are local edits the server has not seen
+func (q *Queue) Pending() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.s.Journal) > 0
+}
+
+// Pull replaces the local queue with the server's, dropping local edits
+func (q *Queue) Pull() error {
+	remote, err := FetchRemote()
+	if err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.adopt(remote)
+}
+
+// Push replaces the server's queue with the local one
+func (q *Queue) Push() error {
+	q.mu.Lock()
+	tracks := append([]api.Track(nil), q.s.Tracks...)
+	pending := len(q.s.Journal)
+	q.mu.Unlock()
+
+	if err := PushRemote(tracks); err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.synced(tracks, pending)
+	return q.save()
+}
+
+// ClearAll empties the queue here and on the server. If the server
+// cannot be reached the local clear is journaled and pushed later.
+func (q *Queue) ClearAll() error {
+	if err := q.Clear(); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	pending := len(q.s.Journal)
+	q.mu.Unlock()
+
+	if err := DeleteRemote(); err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.synced(nil, pending)
+	return q.save()
+}
+
+// adopt takes the server's tracks, keeping the current track current if
+// the server still has it; the caller holds the lock
+func (q *Queue) adopt(remote []api.Track) error {
+	pos := 0
+	if q.s.Pos < len(q.s.Tracks) {
+		id := models.Value(q.s.Tracks[q.s.Pos].Id)
+		for i, t := range remote {
+			if models.Value(t.Id) == id {
+				pos = i
+				break
+			}
+		}
+	}
+	q.s.Tracks, q.s.Pos = remote, pos
+	q.synced(remote, len(q.s.Journal))
+	return q.save()
+}
+
+// synced records that the server holds tracks, which cover the first
+// pending journal entries; edits made meanwhile stay journaled. The
+// caller holds the lock.
+func (q *Queue) synced(tracks []api.Track, pending int) {
+	q.s.Synced = make([]string, len(tracks))
+	for i, t := range tracks {
+		q.s.Synced[i] = models.Value(t.Id)
+	}
+	q.s.RemoteSeen = time.Now()
+	if pending > len(q.s.Journal) {
+		pending = len(q.s.Journal)
+	}
+	q.s.Journal = q.s.Journal[pending:]
+	if len(q.s.Journal) == 0 {
+		q.s.Journal = nil
+	}
+}
+
+// lastEdit is when the newest journaled edit was made
+func (q *Queue) lastEdit() time.Time {
+	if n := len(q.s.Journal); n > 0 {
+		return q.s.Journal[n-1].Time
+	}
+	return time.Now()
+}
+
+func sameIDs(tracks []api.Track, ids []string) bool {
+	if len(tracks) != len(ids) {
+		return false
+	}
+	for i, t := range tracks {
+		if models.Value(t.Id) != ids[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// DefaultSyncDelay is how long edits must settle before they are pushed
+const DefaultSyncDelay = 2 * time.Second
+
+// SyncEvent reports the outcome of a background sync
+type SyncEvent struct {
+	Result SyncResult
+	Err    error
+}
+
+// Syncer syncs a queue in the background: once edits have settled for
+// the delay, and on request
+type Syncer struct {
+	q     *Queue
+	delay time.Duration
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	running sync.Mutex // one sync at a time
+	events  chan SyncEvent
+}
+
+// NewSyncer watches q for edits
+func NewSyncer(q *Queue, delay time.Duration) *Syncer {
+	s := &Syncer{q: q, delay: delay, events: make(chan SyncEvent, 8)}
+	q.OnEdit(s.schedule)
+	return s
+}
+
+// Events delivers the outcome of each sync
+func (s *Syncer) Events() <-chan SyncEvent {
+	return s.events
+}
+
+// schedule (re)starts the debounce timer
+func (s *Syncer) schedule() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.timer = time.AfterFunc(s.delay, s.run)
+}
+
+// Now syncs right away, e.g. to pull on startup
+func (s *Syncer) Now() {
+	go s.run()
+}
+
+func (s *Syncer) run() {
+	s.running.Lock()
+	defer s.running.Unlock()
+	res, err := s.q.Sync()
+	select {
+	case s.events <- SyncEvent{Result: res, Err: err}:
+	default:
+	}
+}
+
+// Close stops watching the queue and pushes edits still waiting for
+// the delay
+func (s *Syncer) Close() {
+	s.q.OnEdit(nil)
+	s.mu.Lock()
+	pending := s.timer != nil && s.timer.Stop()
+	s.mu.Unlock()
+	if pending {
+		s.run()
+	}
+}
@@ -0,0 +1,105 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/mock/mocktest"
+)
+
+// remote fails unless the server's queue holds ids
+func remote(t *testing.T, ids string) {
+	t.Helper()
+	tracks, err := FetchRemote()
+	must(t, err)
+	if got := idsOf(tracks); got != ids {
+		t.Fatalf("server queue is %q, want %q", got, ids)
+	}
+}
+
+func syncs(t *testing.T, q *Queue, action string, conflict bool) {
+	t.Helper()
+	res, err := q.Sync()
+	must(t, err)
+	if res.Action != action || res.Conflict != conflict {
+		t.Fatalf("sync %+v, want %s (conflict %v)", res, action, conflict)
+	}
+}
+
+func TestSyncCopiesTheSideThatChanged(t *testing.T) {
+	mocktest.Start(t)
+	mocktest.Login(t)
+	q := open(t)
+
+	// The fixtures queue t4 and t5 on the server
+	syncs(t, q, Pulled, false)
+	check(t, q, "t4 t5", 0)
+	syncs(t, q, Unchanged, false)
+
+	must(t, q.Replace(tracks("t1", "t2")))
+	syncs(t, q, Pushed, false)
+	remote(t, "t1 t2")
+	if q.Pending() {
+		t.Error("edits still pending after a push")
+	}
+
+	must(t, PushRemote(tracks("t3", "t2", "t1")))
+	syncs(t, q, Pulled, false)
+	check(t, q, "t3 t2 t1", 2) // t1 stays current
+	syncs(t, q, Unchanged, false)
+}
+
+func TestSyncLastWriterWins(t *testing.T) {
+	mocktest.Start(t)
+	mocktest.Login(t)
+	q := open(t)
+	syncs(t, q, Pulled, false)
+	must(t, q.Replace(tracks("t1", "t2")))
+	syncs(t, q, Pushed, false)
+
+	// Both change; the local edit comes after the server was last seen
+	// unchanged, so it may be the newer one and wins
+	must(t, PushRemote(tracks("t5")))
+	must(t, q.Enqueue(track("t3")))
+	syncs(t, q, Pushed, true)
+	remote(t, "t1 t2 t3")
+
+	// Both change again, but this time the local edit is older than the
+	// last look at the server, so the server's change is newer
+	must(t, PushRemote(tracks("t4")))
+	must(t, q.Remove(0))
+	q.mu.Lock()
+	q.s.Journal[len(q.s.Journal)-1].Time = q.s.RemoteSeen.Add(-time.Minute)
+	q.mu.Unlock()
+	syncs(t, q, Pulled, true)
+	check(t, q, "t4", 0)
+	if q.Pending() {
+		t.Error("the losing local edit is still pending")
+	}
+}
+
+func TestClearAllClearsTheServer(t *testing.T) {
+	mocktest.Start(t)
+	mocktest.Login(t)
+	q := open(t)
+	must(t, q.Enqueue(tracks("t1", "t2")...))
+	must(t, q.Push())
+	remote(t, "t1 t2")
+
+	must(t, q.ClearAll())
+	check(t, q, "", 0)
+	remote(t, "")
+	syncs(t, q, Unchanged, false)
+}
+
+func TestSyncNeedsLogin(t *testing.T) {
+	mocktest.Start(t)
+	q := open(t)
+	must(t, q.Enqueue(track("t1")))
+	if _, err := q.Sync(); err == nil {
+		t.Fatal("synced without a session")
+	}
+	if !q.Pending() {
+		t.Error("edit dropped by a failed sync")
+	}
+}
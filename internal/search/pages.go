@@ -0,0 +1,77 @@
+package search
+
+import (
+	"context"
+	"sync"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+	"github.com/adityadeshmukh1/dab-cli/internal/pager"
+)
+
+// Tracks pages through the tracks matching query
+func Tracks(query string) pager.Fetch[api.Track] {
+	return pages(query, api.GetSearchParamsTypeTrack, func(r *Results) []api.Track { return r.Tracks })
+}
+
+// Albums pages through the albums matching query
+func Albums(query string) pager.Fetch[api.Album] {
+	return pages(query, api.GetSearchParamsTypeAlbum, func(r *Results) []api.Album { return r.Albums })
+}
+
+// Artists pages through the artists matching query
+func Artists(query string) pager.Fetch[api.Artist] {
+	return pages(query, api.GetSearchParamsTypeArtist, func(r *Results) []api.Artist { return r.Artists })
+}
+
+// The search API takes a limit but no offset, so every request starts
+// from the first result and there is no asking for just the page wanted.
+// Asking for n pages' worth to show page n would make paging through n
+// pages cost O(n²) results; instead the results fetched so far are kept,
+// and each request asks for at least twice as many as the last, so most
+// pages are served from an earlier answer. The tracks remembered for play
+// and download by number are every result fetched, numbered from the first.
+//
+// No request may ask for more than MaxLimit, so nothing past the first
+// MaxLimit results can be reached. A full answer at the cap may still hide
+// more: the page that ends there says so, and the one after it is empty.
+func pages[T any](query string, kind api.GetSearchParamsType, pick func(*Results) []T) pager.Fetch[T] {
+	var (
+		mu     sync.Mutex
+		got    []T
+		done   bool // the server has nothing past got
+		capped bool // got is all a search returns, whatever lies past it
+	)
+	return func(ctx context.Context, page, limit int) (pager.Page[T], error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		need := page * limit
+		if len(got) < need && !done && !capped {
+			ask := min(max(need, 2*len(got)), MaxLimit)
+			res, err := QueryContext(ctx, query, kind, ask)
+			if err != nil {
+				return pager.Page[T]{}, err
+			}
+			got = pick(res)
+			done = len(got) < ask
+			capped = !done && ask == MaxLimit
+		}
+
+		first, end := min((page-1)*limit, len(got)), min(need, len(got))
+		more := end < len(got) || !done
+		if capped && first == len(got) {
+			more = false
+		}
+		info := api.Pagination{
+			Page:    models.Ptr(page),
+			Limit:   models.Ptr(limit),
+			Loaded:  models.Ptr(end),
+			HasMore: models.Ptr(more),
+		}
+		if done {
+			info.Total = models.Ptr(len(got))
+		}
+		return pager.Page[T]{Number: page, Items: got[first:end], Pagination: info}, nil
+	}
+}
@@ -1,77 +1,157 @@
 package search
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"net/url"
 	"os"
 
-	"github.com/adityadeshmukh1/dab-cli/internal/store"
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/client"
 )
 
-type SearchResponse struct {
-	Tracks []Track `json:"tracks"`
+// Kinds of search, in the order the TUI cycles through them
+var Kinds = []api.GetSearchParamsType{
+	api.GetSearchParamsTypeTrack,
+	api.GetSearchParamsTypeAlbum,
+	api.GetSearchParamsTypeArtist,
 }
 
-type Track struct {
-	ID     int    `json:"id"`
-	Title  string `json:"title"`
-	Artist string `json:"artist"`
+// Results holds the decoded results of one search. Only the slice
+// matching the search kind is filled.
+type Results struct {
+	Kind    api.GetSearchParamsType
+	Tracks  []api.Track
+	Albums  []api.Album
+	Artists []api.Artist
 }
 
-// Load that session cookie!
-func getSessionToken() (string, error) {
-	data, err := os.ReadFile(".session")
-	if err != nil {
-		return "", fmt.Errorf("could not read session file: %v", err)
+// Len is the number of results, whatever their kind
+func (r *Results) Len() int {
+	return len(r.Tracks) + len(r.Albums) + len(r.Artists)
+}
+
+// ParseKind validates a search type given on the command line
+func ParseKind(s string) (api.GetSearchParamsType, error) {
+	for _, k := range Kinds {
+		if string(k) == s {
+			return k, nil
+		}
 	}
-	return string(data), nil
+	return "", fmt.Errorf("unknown search type %q (want track, album or artist)", s)
 }
 
-func Search(query string) ([]Track, error) {
-	token, err := getSessionToken()
+// Search looks up tracks and remembers them for play/download by number
+func Search(query string) ([]api.Track, error) {
+	res, err := Query(query, api.GetSearchParamsTypeTrack, 0)
 	if err != nil {
 		return nil, err
 	}
+	return res.Tracks, nil
+}
+
+// MaxLimit is the most results the server returns for one search
+const MaxLimit = 50
+
+// Query runs a search of the given kind. A zero limit uses the server
+// default; limits past MaxLimit are cut to it.
+func Query(query string, kind api.GetSearchParamsType, limit int) (*Results, error) {
+	return QueryContext(context.Background(), query, kind, limit)
+}
 
-	encodedQuery := url.QueryEscape(query)
-	url := "https://dab.yeet.su/api/search?q=" + encodedQuery
-	req, err := http.NewRequest("GET", url, nil)
+// QueryContext is Query, given up when ctx is cancelled
+func QueryContext(ctx context.Context, query string, kind api.GetSearchParamsType, limit int) (*Results, error) {
+	c, err := client.New()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create search request: %v", err)
+		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.AddCookie(&http.Cookie{Name: "session", Value: token})
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	params := &api.GetSearchParams{Q: query, Type: &kind}
+	if limit > 0 {
+		limit = min(limit, MaxLimit)
+		params.Limit = &limit
+	}
+	resp, err := c.GetSearchWithResponse(ctx, params)
 	if err != nil {
 		return nil, fmt.Errorf("search request failed: %v", err)
 	}
-	defer resp.Body.Close()
+	if resp.StatusCode() != http.StatusOK {
+		return nil, client.ResponseError("search", resp.StatusCode(), resp.Body)
+	}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("search request failed: %s", string(body))
+	res, err := decode(kind, resp)
+	if err != nil {
+		return nil, err
 	}
 
-	var searchRes SearchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&searchRes); err != nil {
-		return nil, fmt.Errorf("failed to parse responseL %v", err)
+	// A search superseded while it finished must not replace the numbers
+	// of the one that superseded it
+	if kind == api.GetSearchParamsTypeTrack && ctx.Err() == nil {
+		remember(res.Tracks)
 	}
+	return res, nil
+}
 
-	// Update store
-	store.ResetSongs()
-	for i, t := range searchRes.Tracks {
-		store.SetSong(i+1, t.ID)
+// The results union has no discriminator, but every item is of the kind
+// that was asked for
+func decode(kind api.GetSearchParamsType, resp *api.GetSearchResponse) (*Results, error) {
+	res := &Results{Kind: kind}
+	if resp.JSON200 == nil || resp.JSON200.Results == nil {
+		return res, nil
 	}
 
-	// Save last search
-	if err := store.SaveToFile(".dabcli_last_search.json"); err != nil {
-		fmt.Printf("Warning: could not save searc results: %v\n", err)
+	for _, item := range *resp.JSON200.Results {
+		var err error
+		switch kind {
+		case api.GetSearchParamsTypeAlbum:
+			var a api.Album
+			if a, err = item.AsAlbum(); err == nil {
+				res.Albums = append(res.Albums, a)
+			}
+		case api.GetSearchParamsTypeArtist:
+			var a api.Artist
+			if a, err = item.AsArtist(); err == nil {
+				res.Artists = append(res.Artists, a)
+			}
+		default:
+			var t api.Track
+			if t, err = item.AsTrack(); err == nil {
+				res.Tracks = append(res.Tracks, t)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s result: %v", kind, err)
+		}
 	}
+	return res, nil
+}
+
+// lastTracksFile keeps the tracks of the last search whole, for commands
+// that need more than their IDs
+const lastTracksFile = ".dabcli_last_tracks.json"
 
-	return searchRes.Tracks, nil
+// remember saves the tracks so they can be picked by their result number
+func remember(tracks []api.Track) {
+	data, err := json.Marshal(tracks)
+	if err == nil {
+		err = os.WriteFile(lastTracksFile, data, 0o644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save search results: %v\n", err)
+	}
+}
+
+// Last returns the tracks of the last track search, in the order they
+// are numbered
+func Last() ([]api.Track, error) {
+	data, err := os.ReadFile(lastTracksFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not load last search results: %v", err)
+	}
+	var tracks []api.Track
+	if err := json.Unmarshal(data, &tracks); err != nil {
+		return nil, fmt.Errorf("could not parse last search results: %v", err)
+	}
+	return tracks, nil
 }
@@ -0,0 +1,218 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/mock"
+	"github.com/adityadeshmukh1/dab-cli/internal/mock/mocktest"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+	"github.com/adityadeshmukh1/dab-cli/internal/pager"
+)
+
+func ids[T any](items []T, id func(T) string) []string {
+	var out []string
+	for _, it := range items {
+		out = append(out, id(it))
+	}
+	return out
+}
+
+func trackIDs(tracks []api.Track) []string {
+	return ids(tracks, func(t api.Track) string { return models.Value(t.Id) })
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestQueryDecodesTheKindAskedFor(t *testing.T) {
+	mocktest.Start(t)
+
+	res, err := Query("a440", api.GetSearchParamsTypeTrack, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Len() != 1 || len(res.Tracks) != 1 {
+		t.Fatalf("track search: got %+v", res)
+	}
+	tr := res.Tracks[0]
+	if models.Value(tr.Title) != "A440" || models.Value(tr.Duration) != 95 {
+		t.Errorf("track decoded as %q, %ds", models.Value(tr.Title), models.Value(tr.Duration))
+	}
+	if tr.AudioQuality == nil || models.Value(tr.AudioQuality.MaximumBitDepth) != 24 {
+		t.Errorf("track audio quality not decoded: %+v", tr.AudioQuality)
+	}
+
+	res, err = Query("waves", api.GetSearchParamsTypeAlbum, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := ids(res.Albums, func(a api.Album) string { return models.Value(a.Title) })
+	if !equal(got, []string{"Sine Waves", "Square Waves"}) || res.Len() != 2 {
+		t.Errorf("album search: got %v, %d results in all", got, res.Len())
+	}
+
+	res, err = Query("mock", api.GetSearchParamsTypeArtist, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = ids(res.Artists, func(a api.Artist) string { return models.Value(a.Name) })
+	if !equal(got, []string{"Mock Orchestra"}) || res.Len() != 1 {
+		t.Errorf("artist search: got %v, %d results in all", got, res.Len())
+	}
+}
+
+func TestQueryRemembersTracksOnly(t *testing.T) {
+	mocktest.Start(t)
+
+	if _, err := Query("mock", api.GetSearchParamsTypeTrack, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Query("waves", api.GetSearchParamsTypeAlbum, 0); err != nil {
+		t.Fatal(err)
+	}
+	last, err := Last()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := trackIDs(last); !equal(got, []string{"t1", "t2", "t3", "t4", "t5"}) {
+		t.Errorf("last tracks %v, want the track search's", got)
+	}
+}
+
+func TestTracksPages(t *testing.T) {
+	mocktest.Start(t)
+
+	whole, err := Query("mock", api.GetSearchParamsTypeTrack, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := trackIDs(whole.Tracks)
+
+	p := pager.New(Tracks("mock"), 2)
+	if err := p.Next(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := trackIDs(p.Items()); !equal(got, want[:2]) || !p.More() {
+		t.Fatalf("first page %v (more %v), want %v", got, p.More(), want[:2])
+	}
+	if err := p.Next(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// Numbers keep counting from the first page
+	last, err := Last()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := trackIDs(last); !equal(got, want[:4]) {
+		t.Errorf("after page 2 the last search holds %v, want %v", got, want[:4])
+	}
+
+	all, err := p.All(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := trackIDs(all); !equal(got, want) {
+		t.Errorf("all pages %v, want %v", got, want)
+	}
+	if total, ok := p.Total(); !ok || total != len(want) || p.Pages() != 3 {
+		t.Errorf("total %d (known %v) over %d pages, want %d over 3", total, ok, p.Pages(), len(want))
+	}
+}
+
+func TestPagerDropsAPageAddedTwice(t *testing.T) {
+	mocktest.Start(t)
+
+	p := pager.New(Albums("waves"), 1)
+	pg, err := p.Fetch(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Add(pg) || p.Add(pg) {
+		t.Fatal("page 1 should be added once")
+	}
+	if len(p.Items()) != 1 || p.Status() != "loaded 1 of ?" {
+		t.Errorf("got %d items, status %q", len(p.Items()), p.Status())
+	}
+}
+
+func TestTracksAsksForGrowingWindows(t *testing.T) {
+	var mu sync.Mutex
+	var limits []string
+	h := mocktest.Handler(t)
+	mocktest.Serve(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/search") {
+			mu.Lock()
+			limits = append(limits, r.URL.Query().Get("limit"))
+			mu.Unlock()
+		}
+		h.ServeHTTP(w, r)
+	}))
+
+	p := pager.New(Tracks("mock"), 1)
+	all, err := p.All(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 5 || p.Pages() != 5 {
+		t.Fatalf("got %d tracks over %d pages, want 5 over 5", len(all), p.Pages())
+	}
+	// Page 4 comes out of the answer to page 3
+	if got := strings.Join(limits, " "); got != "1 2 4 8" {
+		t.Errorf("asked for %s results, want 1 2 4 8", got)
+	}
+}
+
+func TestTracksStopAtTheSearchLimit(t *testing.T) {
+	seed := &mock.Seed{}
+	for i := range 60 {
+		id := strconv.Itoa(i + 1)
+		seed.Tracks = append(seed.Tracks, api.Track{Id: models.Ptr("s" + id), Title: models.Ptr("Song " + id)})
+	}
+	var mu sync.Mutex
+	var limits []string
+	h := mock.New(seed).Handler()
+	mocktest.Serve(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/search") {
+			mu.Lock()
+			limits = append(limits, r.URL.Query().Get("limit"))
+			mu.Unlock()
+		}
+		h.ServeHTTP(w, r)
+	}))
+
+	p := pager.New(Tracks("song"), 20)
+	for range 3 {
+		if err := p.Next(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A full answer at the cap may hide more, so the total stays open
+	if _, ok := p.Total(); len(p.Items()) != MaxLimit || !p.More() || ok {
+		t.Fatalf("after 3 pages: %d tracks, more %v, total known %v", len(p.Items()), p.More(), ok)
+	}
+	all, err := p.All(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != MaxLimit || p.More() {
+		t.Errorf("got %d tracks (more %v), want the first %d", len(all), p.More(), MaxLimit)
+	}
+	if got := strings.Join(limits, " "); got != "20 40 50" {
+		t.Errorf("asked for %s results, want 20 40 50", got)
+	}
+}
@@ -0,0 +1,127 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// FLAC metadata block types
+const (
+	blockPadding       = 1
+	blockVorbisComment = 4
+	blockPicture       = 6
+)
+
+const vendor = "dab-cli"
+
+// writeFLAC copies a FLAC stream, replacing its Vorbis comment and
+// picture blocks and dropping padding. Other blocks are kept as-is.
+func writeFLAC(w io.Writer, r io.Reader, t Tags) error {
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != "fLaC" {
+		return fmt.Errorf("not a FLAC file")
+	}
+
+	type block struct {
+		kind byte
+		data []byte
+	}
+	var blocks []block
+	for last := false; !last; {
+		head := make([]byte, 4)
+		if _, err := io.ReadFull(r, head); err != nil {
+			return fmt.Errorf("failed to read FLAC metadata: %v", err)
+		}
+		last = head[0]&0x80 != 0
+		kind := head[0] & 0x7f
+		size := int64(head[1])<<16 | int64(head[2])<<8 | int64(head[3])
+		if kind == blockVorbisComment || kind == blockPicture || kind == blockPadding {
+			if _, err := io.CopyN(io.Discard, r, size); err != nil {
+				return fmt.Errorf("failed to read FLAC metadata: %v", err)
+			}
+			continue
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return fmt.Errorf("failed to read FLAC metadata: %v", err)
+		}
+		blocks = append(blocks, block{kind, data})
+	}
+
+	blocks = append(blocks, block{blockVorbisComment, vorbisComment(t)})
+	if t.Cover != nil {
+		blocks = append(blocks, block{blockPicture, picture(t.Cover)})
+	}
+
+	var out bytes.Buffer
+	out.WriteString("fLaC")
+	for i, b := range blocks {
+		kind := b.kind
+		if i == len(blocks)-1 {
+			kind |= 0x80
+		}
+		n := len(b.data)
+		out.Write([]byte{kind, byte(n >> 16), byte(n >> 8), byte(n)})
+		out.Write(b.data)
+	}
+	if _, err := out.WriteTo(w); err != nil {
+		return fmt.Errorf("failed to write tags: %v", err)
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		return fmt.Errorf("failed to copy audio: %v", err)
+	}
+	return nil
+}
+
+func vorbisComment(t Tags) []byte {
+	var comments []string
+	add := func(key, value string) {
+		if value != "" {
+			comments = append(comments, key+"="+value)
+		}
+	}
+	add("TITLE", t.Title)
+	add("ARTIST", t.Artist)
+	add("ALBUM", t.Album)
+	add("ALBUMARTIST", t.AlbumArtist)
+	add("DATE", t.Date)
+	add("GENRE", t.Genre)
+	if t.Track > 0 {
+		add("TRACKNUMBER", strconv.Itoa(t.Track))
+	}
+	if t.TrackTotal > 0 {
+		add("TRACKTOTAL", strconv.Itoa(t.TrackTotal))
+	}
+
+	// Vorbis comment lengths are little-endian, unlike the rest of FLAC
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, uint32(len(vendor)))
+	b.WriteString(vendor)
+	binary.Write(&b, binary.LittleEndian, uint32(len(comments)))
+	for _, c := range comments {
+		binary.Write(&b, binary.LittleEndian, uint32(len(c)))
+		b.WriteString(c)
+	}
+	return b.Bytes()
+}
+
+func picture(c *Cover) []byte {
+	width, height, depth := dimensions(c.Data)
+
+	var b bytes.Buffer
+	be := func(v int) { binary.Write(&b, binary.BigEndian, uint32(v)) }
+	be(3) // front cover
+	be(len(c.MIME))
+	b.WriteString(c.MIME)
+	be(0) // empty description
+	be(width)
+	be(height)
+	be(depth)
+	be(0) // not an indexed image
+	be(len(c.Data))
+	b.Write(c.Data)
+	return b.Bytes()
+}
@@ -0,0 +1,128 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf16"
+)
+
+// writeMP3 replaces any ID3v2 tag at the start of r with an ID3v2.3 tag.
+// v2.3 rather than v2.4 because it is what most players read reliably.
+func writeMP3(w io.Writer, r io.Reader, t Tags) error {
+	br := &peekReader{r: r}
+	if err := skipID3(br); err != nil {
+		return err
+	}
+
+	var frames bytes.Buffer
+	text := func(id, value string) {
+		if value != "" {
+			frame(&frames, id, utf16Text(value))
+		}
+	}
+	text("TIT2", t.Title)
+	text("TPE1", t.Artist)
+	text("TALB", t.Album)
+	text("TPE2", t.AlbumArtist)
+	text("TCON", t.Genre)
+	if len(t.Date) >= 4 {
+		text("TYER", t.Date[:4])
+	}
+	if t.Track > 0 {
+		n := strconv.Itoa(t.Track)
+		if t.TrackTotal > 0 {
+			n += "/" + strconv.Itoa(t.TrackTotal)
+		}
+		text("TRCK", n)
+	}
+	if t.Cover != nil {
+		var pic bytes.Buffer
+		pic.WriteByte(0) // ISO-8859-1
+		pic.WriteString(t.Cover.MIME)
+		pic.WriteByte(0)
+		pic.WriteByte(3) // front cover
+		pic.WriteByte(0) // empty description
+		pic.Write(t.Cover.Data)
+		frame(&frames, "APIC", pic.Bytes())
+	}
+
+	header := []byte{'I', 'D', '3', 3, 0, 0}
+	header = append(header, syncsafe(frames.Len())...)
+	if _, err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write tags: %v", err)
+	}
+	if _, err := frames.WriteTo(w); err != nil {
+		return fmt.Errorf("failed to write tags: %v", err)
+	}
+	if _, err := io.Copy(w, br); err != nil {
+		return fmt.Errorf("failed to copy audio: %v", err)
+	}
+	return nil
+}
+
+// skipID3 consumes an existing ID3v2 tag, if any
+func skipID3(r *peekReader) error {
+	head, err := r.peek(10)
+	if err != nil || string(head[:3]) != "ID3" {
+		return nil
+	}
+	size := int64(head[6])<<21 | int64(head[7])<<14 | int64(head[8])<<7 | int64(head[9])
+	size += 10
+	if head[5]&0x10 != 0 { // footer present
+		size += 10
+	}
+	if _, err := io.CopyN(io.Discard, r, size); err != nil {
+		return fmt.Errorf("failed to skip old tag: %v", err)
+	}
+	return nil
+}
+
+func frame(w *bytes.Buffer, id string, body []byte) {
+	w.WriteString(id)
+	binary.Write(w, binary.BigEndian, uint32(len(body)))
+	w.Write([]byte{0, 0})
+	w.Write(body)
+}
+
+// utf16Text encodes a text frame body as UTF-16 with BOM
+func utf16Text(s string) []byte {
+	b := []byte{1, 0xff, 0xfe}
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = append(b, byte(u), byte(u>>8))
+	}
+	return b
+}
+
+func syncsafe(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+}
+
+// peekReader lets the tag header be inspected before it is consumed
+type peekReader struct {
+	r   io.Reader
+	buf []byte
+}
+
+func (p *peekReader) peek(n int) ([]byte, error) {
+	if len(p.buf) < n {
+		more := make([]byte, n-len(p.buf))
+		k, err := io.ReadFull(p.r, more)
+		p.buf = append(p.buf, more[:k]...)
+		if err != nil {
+			return p.buf, err
+		}
+	}
+	return p.buf[:n], nil
+}
+
+func (p *peekReader) Read(b []byte) (int, error) {
+	if len(p.buf) > 0 {
+		n := copy(b, p.buf)
+		p.buf = p.buf[n:]
+		return n, nil
+	}
+	return p.r.Read(b)
+}
@@ -0,0 +1,155 @@
+package tags
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // cover dimensions for FLAC pictures
+	_ "image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adityadeshmukh1/dab-cli/api"
+	"github.com/adityadeshmukh1/dab-cli/internal/models"
+)
+
+// ErrUnsupported is returned for files that are neither MP3 nor FLAC
+var ErrUnsupported = errors.New("unsupported file format for tagging")
+
+// Tags is the metadata written into a downloaded file
+type Tags struct {
+	Title       string
+	Artist      string
+	Album       string
+	AlbumArtist string
+	Date        string // YYYY-MM-DD or YYYY
+	Genre       string
+	Track       int // 0 if unknown
+	TrackTotal  int
+	Cover       *Cover `json:"-"` // fetched per run, not persisted
+}
+
+// Cover is an embedded front-cover image
+type Cover struct {
+	MIME string
+	Data []byte
+}
+
+// FromTrack collects tags from a track and, if known, the album it was
+// downloaded as part of
+func FromTrack(t api.Track, a *api.Album, number, total int) Tags {
+	tg := Tags{
+		Title:      models.Value(t.Title),
+		Artist:     models.Value(t.Artist),
+		Album:      models.Value(t.AlbumTitle),
+		Date:       models.Value(t.ReleaseDate),
+		Genre:      models.Value(t.Genre),
+		Track:      number,
+		TrackTotal: total,
+	}
+	if a != nil {
+		tg.AlbumArtist = models.Value(a.Artist)
+		if tg.Album == "" {
+			tg.Album = models.Value(a.Title)
+		}
+		if tg.Date == "" {
+			tg.Date = models.Value(a.ReleaseDate)
+		}
+		if tg.Genre == "" {
+			tg.Genre = models.Value(a.Genre)
+		}
+	}
+	return tg
+}
+
+// maxCoverBytes keeps covers within a FLAC metadata block
+const maxCoverBytes = 1<<24 - 1024
+
+// FetchCover downloads an album cover for embedding
+func FetchCover(url string) (*Cover, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch cover: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad cover status: %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCoverBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cover: %v", err)
+	}
+	if len(data) > maxCoverBytes {
+		return nil, fmt.Errorf("cover is too large to embed")
+	}
+	mime := http.DetectContentType(data)
+	if !strings.HasPrefix(mime, "image/") {
+		return nil, fmt.Errorf("cover is not an image (%s)", mime)
+	}
+	return &Cover{MIME: mime, Data: data}, nil
+}
+
+// Write tags a file in place, choosing ID3v2 or Vorbis comments from
+// the file's contents
+func Write(path string, t Tags) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	head := make([]byte, 10)
+	if _, err := io.ReadFull(f, head); err != nil {
+		return ErrUnsupported
+	}
+	switch {
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return rewrite(path, f, func(w io.Writer) error { return writeFLAC(w, f, t) })
+	case bytes.HasPrefix(head, []byte("ID3")), head[0] == 0xff && head[1]&0xe0 == 0xe0:
+		return rewrite(path, f, func(w io.Writer) error { return writeMP3(w, f, t) })
+	}
+	return ErrUnsupported
+}
+
+// rewrite writes the tagged copy next to the original and renames it
+// over, so an interrupted write never leaves a half-tagged file
+func rewrite(path string, f *os.File, write func(w io.Writer) error) error {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tagging-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write tags: %v", err)
+	}
+	if info, err := f.Stat(); err == nil {
+		os.Chmod(tmp.Name(), info.Mode())
+	}
+	f.Close()
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %v", path, err)
+	}
+	return nil
+}
+
+// dimensions reads the width, height and bit depth of a cover, or zeros
+func dimensions(data []byte) (int, int, int) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, 0
+	}
+	return cfg.Width, cfg.Height, 24
+}
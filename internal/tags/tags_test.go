@@ -0,0 +1,219 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/mock/mocktest"
+)
+
+var sample = Tags{
+	Title:       "A440",
+	Artist:      "Mock Orchestra",
+	Album:       "Sine Waves",
+	AlbumArtist: "Mock Orchestra",
+	Date:        "2021-03-14",
+	Genre:       "Classical",
+	Track:       1,
+	TrackTotal:  3,
+}
+
+// fetch gets a file from the mock server
+func fetch(t *testing.T, url string) []byte {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// tagged writes data to a file, tags it and returns the result
+func tagged(t *testing.T, data []byte, tg Tags) []byte {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "track")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(path, tg); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+type flacBlock struct {
+	kind byte
+	data []byte
+}
+
+// flacBlocks splits a FLAC file into its metadata blocks and the audio
+func flacBlocks(t *testing.T, data []byte) ([]flacBlock, []byte) {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("fLaC")) {
+		t.Fatal("not a FLAC file")
+	}
+	data = data[4:]
+	var blocks []flacBlock
+	for last := false; !last; {
+		if len(data) < 4 {
+			t.Fatal("truncated FLAC metadata")
+		}
+		last = data[0]&0x80 != 0
+		size := int(data[1])<<16 | int(data[2])<<8 | int(data[3])
+		blocks = append(blocks, flacBlock{data[0] & 0x7f, data[4 : 4+size]})
+		data = data[4+size:]
+	}
+	return blocks, data
+}
+
+// comments decodes a Vorbis comment block
+func comments(data []byte) []string {
+	n := binary.LittleEndian.Uint32(data)
+	data = data[4+n:]
+	count := binary.LittleEndian.Uint32(data)
+	data = data[4:]
+	var out []string
+	for range count {
+		n := binary.LittleEndian.Uint32(data)
+		out = append(out, string(data[4:4+n]))
+		data = data[4+n:]
+	}
+	return out
+}
+
+func TestWriteFLAC(t *testing.T) {
+	srv := mocktest.Start(t)
+	orig := fetch(t, srv.URL+"/audio/t1?quality=27")
+	cover, err := FetchCover(srv.URL + "/covers/al1.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, audio := flacBlocks(t, orig)
+
+	tg := sample
+	tg.Cover = cover
+	// Tag twice: the second run must replace the first, not add to it
+	out := tagged(t, tagged(t, orig, Tags{Title: "Old", Genre: "Noise"}), tg)
+
+	blocks, rest := flacBlocks(t, out)
+	var kinds []byte
+	for _, b := range blocks {
+		kinds = append(kinds, b.kind)
+	}
+	if !bytes.Equal(kinds, []byte{0, blockVorbisComment, blockPicture}) {
+		t.Fatalf("blocks %v, want STREAMINFO, comment, picture", kinds)
+	}
+	if !bytes.Equal(rest, audio) {
+		t.Error("audio frames changed")
+	}
+
+	want := []string{
+		"TITLE=A440", "ARTIST=Mock Orchestra", "ALBUM=Sine Waves", "ALBUMARTIST=Mock Orchestra",
+		"DATE=2021-03-14", "GENRE=Classical", "TRACKNUMBER=1", "TRACKTOTAL=3",
+	}
+	if got := comments(blocks[1].data); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("comments %q, want %q", got, want)
+	}
+
+	pic := blocks[2].data
+	if binary.BigEndian.Uint32(pic) != 3 || !bytes.Contains(pic, []byte("image/png")) || !bytes.HasSuffix(pic, cover.Data) {
+		t.Error("picture block is not the front cover")
+	}
+	// The mock's covers are 64x64
+	mimeEnd := 8 + len(cover.MIME)
+	if w, h := binary.BigEndian.Uint32(pic[mimeEnd+4:]), binary.BigEndian.Uint32(pic[mimeEnd+8:]); w != 64 || h != 64 {
+		t.Errorf("picture is %dx%d, want 64x64", w, h)
+	}
+}
+
+// id3Frames reads an ID3v2.3 tag's frames and returns what follows it
+func id3Frames(t *testing.T, data []byte) (map[string][]byte, []byte) {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("ID3\x03")) {
+		t.Fatal("no ID3v2.3 tag")
+	}
+	size := int(data[6])<<21 | int(data[7])<<14 | int(data[8])<<7 | int(data[9])
+	body, rest := data[10:10+size], data[10+size:]
+	frames := map[string][]byte{}
+	for len(body) >= 10 {
+		n := int(binary.BigEndian.Uint32(body[4:]))
+		frames[string(body[:4])] = body[10 : 10+n]
+		body = body[10+n:]
+	}
+	return frames, rest
+}
+
+// text decodes a UTF-16 text frame
+func text(body []byte) string {
+	if len(body) < 3 || body[0] != 1 {
+		return ""
+	}
+	var units []uint16
+	for i := 3; i+1 < len(body); i += 2 {
+		units = append(units, uint16(body[i])|uint16(body[i+1])<<8)
+	}
+	return string(utf16.Decode(units))
+}
+
+func TestWriteMP3(t *testing.T) {
+	srv := mocktest.Start(t)
+	orig := fetch(t, srv.URL+"/audio/t1?quality=5")
+	cover, err := FetchCover(srv.URL + "/covers/al1.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tg := sample
+	tg.Title = "Ä440 ♪"
+	tg.Cover = cover
+	out := tagged(t, tagged(t, orig, Tags{Title: "Old"}), tg)
+
+	frames, rest := id3Frames(t, out)
+	if !bytes.Equal(rest, orig) {
+		t.Error("audio frames changed, or the old tag was kept")
+	}
+	for id, want := range map[string]string{
+		"TIT2": "Ä440 ♪", "TPE1": "Mock Orchestra", "TALB": "Sine Waves", "TPE2": "Mock Orchestra",
+		"TCON": "Classical", "TYER": "2021", "TRCK": "1/3",
+	} {
+		if got := text(frames[id]); got != want {
+			t.Errorf("%s = %q, want %q", id, got, want)
+		}
+	}
+	apic := frames["APIC"]
+	if !bytes.HasPrefix(apic, []byte("\x00image/png\x00\x03\x00")) || !bytes.HasSuffix(apic, cover.Data) {
+		t.Error("APIC frame is not the front cover")
+	}
+}
+
+func TestWriteUnsupported(t *testing.T) {
+	srv := mocktest.Start(t)
+	path := filepath.Join(t.TempDir(), "track.wav")
+	wav := fetch(t, srv.URL+"/audio/t1")
+	if err := os.WriteFile(path, wav, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(path, sample); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("got %v, want ErrUnsupported", err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, wav) {
+		t.Error("unsupported file was changed")
+	}
+}
@@ -0,0 +1,131 @@
+package transcode
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/adityadeshmukh1/dab-cli/internal/config"
+)
+
+// Profile is a named ffmpeg encoding: which codec, container and
+// bitrate (or VBR quality) to produce
+type Profile struct {
+	Name    string   `json:"name"`
+	Codec   string   `json:"codec"`
+	Format  string   `json:"format"` // ffmpeg muxer
+	Ext     string   `json:"ext"`    // file extension, with the dot
+	Bitrate string   `json:"bitrate,omitempty"`
+	Quality string   `json:"quality,omitempty"` // VBR quality (-q:a), instead of a bitrate
+	Args    []string `json:"args,omitempty"`    // extra output options
+}
+
+// builtin profiles. low/medium/high/flac are the playback presets.
+var builtin = map[string]Profile{
+	"low":    {Codec: "libmp3lame", Format: "mp3", Ext: ".mp3", Bitrate: "96k"},
+	"medium": {Codec: "libmp3lame", Format: "mp3", Ext: ".mp3", Bitrate: "160k"},
+	"high":   {Codec: "libmp3lame", Format: "mp3", Ext: ".mp3", Bitrate: "256k"},
+	"flac":   {Codec: "flac", Format: "flac", Ext: ".flac"},
+	"mp3-v0": {Codec: "libmp3lame", Format: "mp3", Ext: ".mp3", Quality: "0"},
+	"opus":   {Codec: "libopus", Format: "ogg", Ext: ".opus", Bitrate: "128k"},
+	"aac":    {Codec: "aac", Format: "ipod", Ext: ".m4a", Bitrate: "256k"},
+}
+
+// Fallback is used for playback when a quality names no profile
+var Fallback = Profile{Name: "default", Codec: "libmp3lame", Format: "mp3", Ext: ".mp3", Bitrate: "192k"}
+
+// Profiles returns the built-in profiles merged with those in the
+// config file, which win on a name clash
+func Profiles() map[string]Profile {
+	all := make(map[string]Profile, len(builtin))
+	for name, p := range builtin {
+		p.Name = name
+		all[name] = p
+	}
+	for name, c := range config.Active().Downloads.Profiles {
+		p := Profile{Name: name, Codec: c.Codec, Format: c.Format, Ext: c.Ext, Bitrate: c.Bitrate, Quality: c.Quality, Args: c.Args}
+		if p.Ext != "" && !strings.HasPrefix(p.Ext, ".") {
+			p.Ext = "." + p.Ext
+		}
+		if p.Ext == "" {
+			p.Ext = "." + p.Format
+		}
+		all[name] = p
+	}
+	return all
+}
+
+// Names lists the profiles in a stable order
+func Names() []string {
+	var names []string
+	for name := range Profiles() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup finds a profile by name
+func Lookup(name string) (Profile, error) {
+	p, ok := Profiles()[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown transcode profile %q (have: %s)", name, strings.Join(Names(), ", "))
+	}
+	if p.Codec == "" || p.Format == "" {
+		return Profile{}, fmt.Errorf("transcode profile %q needs a codec and a format", name)
+	}
+	return p, nil
+}
+
+// Available reports whether ffmpeg can be run
+func Available() error {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return fmt.Errorf("transcoding needs ffmpeg on your PATH")
+	}
+	return nil
+}
+
+// OutputArgs are the ffmpeg options that encode to the profile
+func (p Profile) OutputArgs() []string {
+	args := []string{"-c:a", p.Codec}
+	if p.Bitrate != "" {
+		args = append(args, "-b:a", p.Bitrate)
+	}
+	if p.Quality != "" {
+		args = append(args, "-q:a", p.Quality)
+	}
+	args = append(args, p.Args...)
+	return append(args, "-f", p.Format)
+}
+
+// File encodes in to out. metadata is written as container tags, for
+// formats the tag writer does not handle itself.
+func File(in, out string, p Profile, metadata map[string]string) error {
+	args := []string{"-hide_banner", "-loglevel", "error", "-y", "-i", in, "-map", "0:a", "-map_metadata", "-1"}
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if metadata[k] != "" {
+			args = append(args, "-metadata", k+"="+metadata[k])
+		}
+	}
+	args = append(args, p.OutputArgs()...)
+	args = append(args, out)
+
+	cmd := exec.Command("ffmpeg", args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(out)
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("ffmpeg (%s): %s", p.Name, msg)
+		}
+		return fmt.Errorf("ffmpeg (%s): %v", p.Name, err)
+	}
+	return nil
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"os"
+
 	"github.com/adityadeshmukh1/dab-cli/cmd"
 )
 
 func main() {
-	cmd.RunTUI()
+	os.Exit(cmd.Run(os.Args))
 }